@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// dbError 仓储返回的错误：已是领域错误（如 repositories.ErrorVersionConflict）时原样返回，否则包装为 models.ErrInternal
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := models.AsDomainError(err); ok {
+		return err
+	}
+	return models.ErrInternal.Wrap(err)
+}
+
+// notFound 仓储返回 repositories.ErrorNotFound 时以 notFoundErr（如 models.ErrUserNotFound）包装，其他错误同 dbError
+func notFound(err error, notFoundErr *models.DomainError) error {
+	if errors.Is(err, repositories.ErrorNotFound) {
+		return notFoundErr.Wrap(err)
+	}
+	return dbError(err)
+}
+
+// unexpectedRows 增量更新、条件更新影响的行数不是预期的1行
+func unexpectedRows(table string, affected int8) error {
+	return models.ErrInternal.With("table", table).With("affected_rows", affected)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// eventSource 记录领域事件的聚合（models.User、models.Order）
+type eventSource interface {
+	PullEvents() []models.DomainEvent
+}
+
+// publishAfterCommit 登记在最外层事务提交后取出并分发 sources 的领域事件，dispatcher 为空时跳过
+// 事件在提交后才取出：此时聚合ID已分配，事务回滚或被重试时登记的分发随之丢弃
+// 事务已提交，订阅者的错误只通过 logger 记录，不影响本次操作的结果
+func publishAfterCommit(txCtx context.Context, tm repositories.TransactionManager,
+	dispatcher repositories.EventDispatcher, logger repositories.Logger, sources ...eventSource) {
+	if dispatcher == nil {
+		return
+	}
+	tm.AfterCommit(txCtx, func(ctx context.Context) {
+		var events []models.DomainEvent
+		for _, source := range sources {
+			events = append(events, source.PullEvents()...)
+		}
+		if len(events) == 0 {
+			return
+		}
+		if err := dispatcher.Dispatch(ctx, events...); err != nil {
+			logger.Log(ctx, "log.event_dispatch_failed", map[string]any{"error": err})
+		}
+	})
+}
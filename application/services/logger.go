@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+	"log"
+)
+
+// keyLogger 未配置 repositories.Logger 时使用：只输出文案键与参数，不绑定任何语言
+type keyLogger struct{}
+
+func (keyLogger) Log(_ context.Context, key string, params map[string]any) {
+	log.Printf("%s %v", key, params)
+}
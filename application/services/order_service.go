@@ -1,66 +1,330 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
-	// "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 )
 
+// ErrIdempotencyKeyConflict 幂等键已被内容不同的请求使用，详情 key 为幂等键
+var ErrIdempotencyKeyConflict = models.NewDomainError(models.CodeIdempotencyKeyConflict, models.CategoryConflict, "幂等键已用于不同的订单请求")
+
 // OrderAppService 订单应用服务（事务编排中心）
 type OrderAppService struct {
-	userRepo  repositories.UserRepository
-	orderRepo repositories.OrderRepository
-	txManager repositories.TransactionManager // 事务管理器
+	userRepo        repositories.UserRepository
+	orderRepo       repositories.OrderRepository
+	txManager       repositories.TransactionManager     // 事务管理器
+	outboxRepo      repositories.OutboxRepository       // 可选，为空时不写入发件箱
+	idempotencyRepo repositories.IdempotencyRepository  // 可选，为空时不支持幂等键
+	rateRepo        repositories.ExchangeRateRepository // 可选，为空时只支持基准币种
+	refundRepo      repositories.RefundRepository       // 可选，为空时不支持退款
+	productRepo     repositories.ProductRepository      // 可选，为空时不支持按商品下单
+	orderItemRepo   repositories.OrderItemRepository
+	tierRepo        repositories.TierRepository  // 可选，为空时不计算客户等级
+	defaultLimits   models.SpendingLimits        // 用户未单独设置时的消费限额，默认不限制
+	dispatcher      repositories.EventDispatcher // 可选，为空时不分发领域事件
+	logger          repositories.Logger          // 记录订阅者错误等运行日志
+}
+
+// OrderServiceOption 订单应用服务的可选依赖
+type OrderServiceOption func(*OrderAppService)
+
+// WithOutbox 在订单事务中把领域事件写入发件箱
+func WithOutbox(repo repositories.OutboxRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.outboxRepo = repo
+	}
+}
+
+// WithIdempotency 启用 CreateOrderCommand.IdempotencyKey
+func WithIdempotency(repo repositories.IdempotencyRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.idempotencyRepo = repo
+	}
+}
+
+// WithExchangeRates 支持以非基准币种下单，消费总额按下单时生效的汇率折算
+func WithExchangeRates(repo repositories.ExchangeRateRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.rateRepo = repo
+	}
+}
+
+// WithRefunds 启用 RefundOrder 及退款记录查询
+func WithRefunds(repo repositories.RefundRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.refundRepo = repo
+	}
+}
+
+// WithCatalog 启用 CreateOrderCommand.Items，按商品目录的价格生成订单明细
+func WithCatalog(products repositories.ProductRepository, items repositories.OrderItemRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.productRepo = products
+		s.orderItemRepo = items
+	}
+}
+
+// WithTiers 消费总额变化时在同一事务中重新计算客户等级并记录等级变化
+func WithTiers(repo repositories.TierRepository) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.tierRepo = repo
+	}
+}
+
+// WithSpendingLimits 设置默认的消费限额，用户单独设置的限额优先
+func WithSpendingLimits(defaults models.SpendingLimits) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.defaultLimits = defaults
+	}
+}
+
+// WithEventDispatcher 在事务提交后把订单与用户记录的领域事件交给 dispatcher
+func WithEventDispatcher(dispatcher repositories.EventDispatcher) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.dispatcher = dispatcher
+	}
+}
+
+// WithLogger 使用 logger 输出运行日志（如本地化的文案），默认只输出文案键与参数
+func WithLogger(logger repositories.Logger) OrderServiceOption {
+	return func(s *OrderAppService) {
+		s.logger = logger
+	}
 }
 
 func NewOrderService(ur repositories.UserRepository, or repositories.OrderRepository,
-	tm repositories.TransactionManager) *OrderAppService {
-	return &OrderAppService{userRepo: ur, orderRepo: or, txManager: tm}
+	tm repositories.TransactionManager, opts ...OrderServiceOption) *OrderAppService {
+	s := &OrderAppService{userRepo: ur, orderRepo: or, txManager: tm, logger: keyLogger{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateOrderCommand 创建订单命令
+// 指定 Items 时订单金额由商品单价与数量计算，不能再指定 Amount
 type CreateOrderCommand struct {
-	UserID uint64
-	Amount float64 // 订单金额
+	UserID         uint64
+	Amount         models.Money       // 订单金额（Currency 币种）
+	Currency       models.Currency    // 可选，默认为基准币种
+	Items          []OrderItemCommand // 可选，订单明细
+	IdempotencyKey string             // 可选，相同的键只会创建一次订单
+}
+
+// OrderItemCommand 订单明细：商品SKU与数量
+type OrderItemCommand struct {
+	SKU      string
+	Quantity int
 }
 
-// CreateOrder 业务流程
-func (s *OrderAppService) CreateOrder(cmd CreateOrderCommand) error {
+// requestHash 命令内容摘要，用于判断相同幂等键的请求内容是否一致
+// 基准币种、没有明细的订单只包含用户与金额，与引入币种之前写入的摘要保持一致
+func (cmd CreateOrderCommand) requestHash() string {
+	content := fmt.Sprintf("%d|%s", cmd.UserID, cmd.Amount)
+	if currency, err := models.ParseCurrency(string(cmd.Currency)); err == nil && currency != models.BaseCurrency {
+		content += "|" + string(currency)
+	}
+	for _, item := range cmd.Items {
+		content += fmt.Sprintf("|%s*%d", item.SKU, item.Quantity)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateOrder 业务流程，返回订单ID
+// 携带 IdempotencyKey 的重复请求直接返回第一次创建的订单ID，不会重复下单
+func (s *OrderAppService) CreateOrder(ctx context.Context, cmd CreateOrderCommand) (uint64, error) {
+	// 0. 幂等检查
+	if cmd.IdempotencyKey != "" {
+		if s.idempotencyRepo == nil {
+			return 0, models.ErrNotEnabled.With("feature", "idempotency")
+		}
+		if orderID, found, err := s.findIdempotent(ctx, cmd); err != nil || found {
+			return orderID, err
+		}
+	}
+
 	// 1. 获取用户（不再自动创建）
-	user, err := s.userRepo.FindByID(cmd.UserID)
-	if errors.Is(err, repositories.ErrorNotFound) {
-		return errors.New("用户不存在") // 明确返回业务错误
-	} else if err != nil {
-		return err
+	user, err := s.userRepo.FindByID(ctx, cmd.UserID)
+	if err != nil {
+		return 0, notFound(err, models.ErrUserNotFound.With("user_id", cmd.UserID)) // 明确返回业务错误
 	}
 
-	// 2. 生成订单
-	order, err := user.CreateOrder(user.ID, cmd.Amount)
+	// 2. 生成订单（按下单时生效的汇率折算为基准币种）
+	rate, err := s.exchangeRate(ctx, cmd.Currency)
+	if err != nil {
+		return 0, err
+	}
+	order, err := s.newOrder(ctx, user, cmd, rate)
 	if err != nil {
-		return fmt.Errorf("订单创建失败: %w", err)
+		return 0, err
 	}
 
-	// 3. 金额校验
-	if err := user.AddConsumption(cmd.Amount); err != nil {
-		return fmt.Errorf("金额校验失败: %w", err)
+	// 3. 金额校验（实际写库使用增量更新，避免并发下单时丢失更新）
+	if err := user.AddConsumption(order.BaseAmount); err != nil {
+		return 0, err
 	}
 
-	// 4. 开启事务
-	return s.txManager.Transaction(func() error {
-		affect_num, err := s.userRepo.UpdateTotalConsumption(user)
-		if affect_num != 1 {
-			return errors.New("users表更新行数错误")
+	// 4. 开启事务（事务内的仓储调用必须使用 txCtx）
+	err = s.txManager.Transaction(ctx, func(txCtx context.Context) error {
+		// 死锁或锁等待超时时整个事务会重试，上一次尝试写入的ID已随回滚作废，重新插入
+		order.OrderID = 0
+		for i := range order.Items {
+			order.Items[i].ID = 0
 		}
+		publishAfterCommit(txCtx, s.txManager, s.dispatcher, s.logger, order, user)
+		affect_num, err := s.userRepo.AddTotalConsumption(txCtx, user.ID, order.BaseAmount)
 		if err != nil {
+			return dbError(err)
+		}
+		if affect_num != 1 {
+			return unexpectedRows("users", affect_num)
+		}
+		if err := s.checkSpendingLimits(txCtx, user, order.BaseAmount); err != nil {
 			return err
 		}
-		if _, err = s.orderRepo.Save(order); err != nil {
+		if order.OrderID, err = s.orderRepo.Save(txCtx, order); err != nil {
+			return dbError(err)
+		}
+		if err := s.recalculateTier(txCtx, user.ID, order.OrderID); err != nil {
 			return err
 		}
-		return nil
+		if len(order.Items) > 0 {
+			for i := range order.Items {
+				order.Items[i].OrderID = order.OrderID
+			}
+			if err := s.orderItemRepo.Save(txCtx, order.Items); err != nil {
+				return dbError(err)
+			}
+			// 扣减库存：库存不足时整个订单回滚
+			if err := s.adjustStock(txCtx, order.Items, s.productRepo.DecrementStock); err != nil {
+				return err
+			}
+		}
+		if cmd.IdempotencyKey != "" {
+			// 与订单在同一事务中写入：并发的相同请求只有一个能提交
+			if err := s.idempotencyRepo.Save(txCtx, &models.IdempotencyRecord{
+				Key:         cmd.IdempotencyKey,
+				RequestHash: cmd.requestHash(),
+				ResourceID:  order.OrderID,
+			}); err != nil {
+				return dbError(err)
+			}
+		}
+		return s.saveEvents(txCtx, models.EventOrderCreated, order, order.BaseAmount)
 	})
+	if cmd.IdempotencyKey != "" && errors.Is(err, repositories.ErrorDuplicate) {
+		// 并发的相同请求已先提交，本次事务已回滚；查不到幂等键时重复来自其他唯一索引，按原错误返回
+		orderID, found, findErr := s.findIdempotent(ctx, cmd)
+		if findErr != nil || found {
+			return orderID, findErr
+		}
+	}
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return order.OrderID, nil
+}
+
+// checkSpendingLimits 检查下单金额是否超过用户的消费限额，超过时返回 *models.LimitExceededError
+// 必须在 AddTotalConsumption 之后调用：增量更新已持有该用户行的锁，同一用户的并发下单依次统计最近的订单
+func (s *OrderAppService) checkSpendingLimits(txCtx context.Context, user *models.User, amount models.Money) error {
+	limits := user.EffectiveLimits(s.defaultLimits)
+	var recent models.Money
+	if !limits.RollingCap.IsZero() {
+		orders, err := s.orderRepo.FindByUserIDSince(txCtx, user.ID, time.Now().Add(-models.SpendingWindow))
+		if err != nil {
+			return dbError(err)
+		}
+		recent = models.RecentSpending(orders)
+	}
+	return limits.Check(amount, recent)
+}
+
+// newOrder 按金额或按商品明细生成订单
+func (s *OrderAppService) newOrder(ctx context.Context, user *models.User, cmd CreateOrderCommand, rate *models.ExchangeRate) (*models.Order, error) {
+	if len(cmd.Items) == 0 {
+		return user.CreateOrder(user.ID, cmd.Amount, rate)
+	}
+	if s.productRepo == nil {
+		return nil, models.ErrNotEnabled.With("feature", "catalog")
+	}
+	if !cmd.Amount.IsZero() {
+		// 指定订单明细时订单金额由明细计算，不能同时指定金额
+		return nil, models.ErrInvalidArgument.With("field", "amount").With("reason", "amount_with_items")
+	}
+
+	items := make([]models.OrderItem, 0, len(cmd.Items))
+	for _, line := range cmd.Items {
+		product, err := s.productRepo.FindBySKU(ctx, line.SKU)
+		if err != nil {
+			return nil, notFound(err, models.ErrProductNotFound.With("sku", line.SKU))
+		}
+		if err := product.CheckStock(line.Quantity); err != nil {
+			return nil, err
+		}
+		item, err := models.NewOrderItem(product, line.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return user.CreateOrderFromItems(user.ID, items, rate)
+}
+
+// adjustStock 按商品ID顺序对每个明细执行库存调整，多个订单并发时以相同顺序加锁，减少死锁
+func (s *OrderAppService) adjustStock(txCtx context.Context, items []models.OrderItem,
+	adjust func(ctx context.Context, productID uint64, quantity int) error) error {
+	sorted := make([]models.OrderItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ProductID < sorted[j].ProductID })
+	for _, item := range sorted {
+		if err := adjust(txCtx, item.ProductID, item.Quantity); err != nil {
+			return dbError(err)
+		}
+	}
+	return nil
+}
+
+// exchangeRate 查找当前生效的汇率，基准币种不需要查询
+func (s *OrderAppService) exchangeRate(ctx context.Context, code models.Currency) (*models.ExchangeRate, error) {
+	currency, err := models.ParseCurrency(string(code))
+	if err != nil {
+		return nil, err
+	}
+	if currency == models.BaseCurrency {
+		return models.BaseExchangeRate(), nil
+	}
+	if s.rateRepo == nil {
+		return nil, models.ErrNotEnabled.With("feature", "exchange_rates").With("currency", currency)
+	}
+	rate, err := s.rateRepo.FindEffective(ctx, currency, time.Now())
+	if err != nil {
+		return nil, notFound(err, models.ErrExchangeRateNotFound.With("currency", currency))
+	}
+	return rate, nil
+}
+
+// findIdempotent 查找幂等键对应的订单；键存在但请求内容不同时返回 ErrIdempotencyKeyConflict
+func (s *OrderAppService) findIdempotent(ctx context.Context, cmd CreateOrderCommand) (uint64, bool, error) {
+	record, err := s.idempotencyRepo.FindByKey(ctx, cmd.IdempotencyKey)
+	if errors.Is(err, repositories.ErrorNotFound) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, dbError(err)
+	}
+	if record.RequestHash != cmd.requestHash() {
+		return 0, true, ErrIdempotencyKeyConflict.With("key", cmd.IdempotencyKey)
+	}
+	return record.ResourceID, true, nil
 }
 
 // InvalidateOrderCommand 订单失效命令
@@ -68,52 +332,259 @@ type InvalidateOrderCommand struct {
 	OrderID uint64
 }
 
-// InvalidateOrder 订单失效流程
-func (s *OrderAppService) InvalidateOrder(cmd InvalidateOrderCommand) error {
-	// 获取订单
-	order, err := s.orderRepo.FindByID(cmd.OrderID)
-	if errors.Is(err, repositories.ErrorInvalid) {
-		return errors.New("订单不存在")
-	} else if err != nil {
+// InvalidateOrder 订单失效流程（取消订单并扣除消费总额）
+// 只能取消 pending / paid 的订单；shipped / completed 的订单返回 models.ErrInvalidTransition，应使用 RefundOrder
+// 订单写入以读取时的状态和版本号为条件，被并发修改时重新读取并重试
+func (s *OrderAppService) InvalidateOrder(ctx context.Context, cmd InvalidateOrderCommand) error {
+	return retryOnConflict(func() error {
+		return s.changeOrderStatus(ctx, cmd.OrderID, models.EventOrderInvalidated, func(order *models.Order) (models.Money, error) {
+			amount, err := order.Invalidate()
+			return amount.Neg(), err
+		})
+	})
+}
+
+// ChangeOrderStatusCommand 订单状态变更命令
+type ChangeOrderStatusCommand struct {
+	OrderID uint64
+	Status  models.OrderStatus // 目标状态，必须是当前状态允许的下一个状态
+}
+
+// ChangeOrderStatus 按订单状态机变更状态，并按状态对消费总额的影响同步调整用户消费总额
+func (s *OrderAppService) ChangeOrderStatus(ctx context.Context, cmd ChangeOrderStatusCommand) error {
+	return retryOnConflict(func() error {
+		return s.changeOrderStatus(ctx, cmd.OrderID, models.EventOrderStatusChanged, func(order *models.Order) (models.Money, error) {
+			return order.TransitionTo(cmd.Status)
+		})
+	})
+}
+
+// RefundOrderCommand 退款命令
+type RefundOrderCommand struct {
+	OrderID uint64
+	Amount  models.Money // 退款金额（订单币种），可以小于订单金额
+	Reason  string
+}
+
+// RefundOrder 订单退款流程，返回退款记录ID
+// 每次退款单独记录，累计退款不超过订单金额；退款的基准币种金额在同一事务中从消费总额扣除
+func (s *OrderAppService) RefundOrder(ctx context.Context, cmd RefundOrderCommand) (uint64, error) {
+	if s.refundRepo == nil {
+		return 0, models.ErrNotEnabled.With("feature", "refunds")
+	}
+	var refundID uint64
+	err := retryOnConflict(func() error {
+		var err error
+		refundID, err = s.refundOrder(ctx, cmd)
 		return err
+	})
+	return refundID, err
+}
+
+func (s *OrderAppService) refundOrder(ctx context.Context, cmd RefundOrderCommand) (uint64, error) {
+	// 已注销用户的历史订单仍可退款，消费总额照常调整
+	ctx = repositories.IncludeDeleted(ctx)
+	// 获取订单
+	order, err := s.orderRepo.FindByID(ctx, cmd.OrderID)
+	if err != nil {
+		return 0, notFound(err, models.ErrOrderNotFound.With("order_id", cmd.OrderID))
 	}
 
-	// 检查订单是否有效
-	if order.IsValid == false {
-		return errors.New("订单已失效")
+	// 退款（领域内校验状态与可退金额）
+	from := order.Status
+	refund, err := order.Refund(cmd.Amount, cmd.Reason)
+	if err != nil {
+		return 0, err
 	}
+	delta := refund.BaseAmount.Neg()
 
 	// 检查是否有对应用户
-	user, err := s.userRepo.FindByID(order.UserID)
-	if errors.Is(err, repositories.ErrorNotFound) {
-		return errors.New(fmt.Sprintf("用户%d不存在", order.UserID))
-	} else if err != nil {
+	user, err := s.userRepo.FindByID(ctx, order.UserID)
+	if err != nil {
+		return 0, notFound(err, models.ErrUserNotFound.With("user_id", order.UserID))
+	}
+	if err := user.AddConsumption(delta); err != nil {
+		return 0, err
+	}
+
+	// 开启事务：订单的累计退款以读取时的状态和版本号为条件写入，并发退款不会超过订单金额
+	err = s.txManager.Transaction(ctx, func(txCtx context.Context) error {
+		// 事务重试时上一次尝试写入的退款ID已随回滚作废
+		refund.ID = 0
+		publishAfterCommit(txCtx, s.txManager, s.dispatcher, s.logger, order, user)
+		affect_num, err := s.orderRepo.UpdateRefund(txCtx, order, from)
+		if err != nil {
+			return dbError(err)
+		}
+		if affect_num != 1 {
+			return unexpectedRows("orders", affect_num)
+		}
+
+		affect_num, err = s.userRepo.AddTotalConsumption(txCtx, user.ID, delta)
+		if err != nil {
+			return dbError(err)
+		}
+		if affect_num != 1 {
+			return unexpectedRows("users", affect_num)
+		}
+
+		if err := s.recalculateTier(txCtx, user.ID, order.OrderID); err != nil {
+			return err
+		}
+		if refund.ID, err = s.refundRepo.Save(txCtx, refund); err != nil {
+			return dbError(err)
+		}
+		return s.saveEvents(txCtx, models.EventOrderRefunded, order, delta)
+	})
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return refund.ID, nil
+}
+
+// RefundHistory 查询订单的退款记录，按退款先后顺序返回
+func (s *OrderAppService) RefundHistory(ctx context.Context, orderID uint64) ([]*models.Refund, error) {
+	if s.refundRepo == nil {
+		return nil, models.ErrNotEnabled.With("feature", "refunds")
+	}
+	if _, err := s.orderRepo.FindByID(ctx, orderID); err != nil {
+		return nil, notFound(err, models.ErrOrderNotFound.With("order_id", orderID))
+	}
+	refunds, err := s.refundRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return refunds, nil
+}
+
+// changeOrderStatus 读取订单并执行 transition，transition 返回状态变更对消费总额的影响
+func (s *OrderAppService) changeOrderStatus(ctx context.Context, orderID uint64, eventType string,
+	transition func(order *models.Order) (models.Money, error)) error {
+	// 已注销用户的历史订单仍可变更状态，消费总额照常调整
+	ctx = repositories.IncludeDeleted(ctx)
+	// 获取订单
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return notFound(err, models.ErrOrderNotFound.With("order_id", orderID))
+	}
+
+	// 状态变更（领域内校验是否允许）
+	from := order.Status
+	delta, err := transition(order)
+	if err != nil {
 		return err
 	}
 
-	// 扣除
-	err = user.AddConsumption(-order.Amount)
+	// 检查是否有对应用户
+	user, err := s.userRepo.FindByID(ctx, order.UserID)
 	if err != nil {
+		return notFound(err, models.ErrUserNotFound.With("user_id", order.UserID))
+	}
+
+	// 调整消费总额（仅做领域校验，写库使用增量更新）
+	if err := user.AddConsumption(delta); err != nil {
 		return err
 	}
 
 	// 开启事务
-	return s.txManager.Transaction(func() error {
-		affect_num, err := s.orderRepo.UpdateValidity(order.OrderID, false)
+	err = s.txManager.Transaction(ctx, func(txCtx context.Context) error {
+		publishAfterCommit(txCtx, s.txManager, s.dispatcher, s.logger, order, user)
+		affect_num, err := s.orderRepo.UpdateStatus(txCtx, order, from)
 		if err != nil {
-			return err
+			return dbError(err)
 		}
 		if affect_num != 1 {
-			return errors.New("orders表更新行数错误")
+			return unexpectedRows("orders", affect_num)
 		}
 
-		affect_num, err = s.userRepo.UpdateTotalConsumption(user)
-		if err != nil {
-			return err
+		if order.Status.ReleasesStock() && !from.ReleasesStock() && len(order.Items) > 0 {
+			// 取消订单时归还库存
+			if s.productRepo == nil {
+				return models.ErrNotEnabled.With("feature", "catalog")
+			}
+			if err := s.adjustStock(txCtx, order.Items, s.productRepo.IncrementStock); err != nil {
+				return err
+			}
 		}
-		if affect_num != 1 {
-			return errors.New("users表更新行数错误")
+
+		if !delta.IsZero() {
+			affect_num, err = s.userRepo.AddTotalConsumption(txCtx, user.ID, delta)
+			if err != nil {
+				return dbError(err)
+			}
+			if affect_num != 1 {
+				return unexpectedRows("users", affect_num)
+			}
+			if err := s.recalculateTier(txCtx, user.ID, order.OrderID); err != nil {
+				return err
+			}
 		}
+		return s.saveEvents(txCtx, eventType, order, delta)
+	})
+	return dbError(err)
+}
+
+// recalculateTier 在事务内按最新的消费总额重新计算用户等级（未配置等级时跳过）
+// 必须在 AddTotalConsumption 之后调用：增量更新已持有该用户行的锁，加锁读取到的总额包含本次变化
+func (s *OrderAppService) recalculateTier(txCtx context.Context, userID uint64, orderID uint64) error {
+	if s.tierRepo == nil {
 		return nil
+	}
+	schedule, err := s.tierRepo.FindThresholds(txCtx)
+	if err != nil {
+		return dbError(err)
+	}
+	user, err := s.userRepo.FindByIDForUpdate(txCtx, userID, repositories.LockWait)
+	if err != nil {
+		return notFound(err, models.ErrUserNotFound.With("user_id", userID))
+	}
+	change := user.RecalculateTier(schedule, orderID)
+	if change == nil {
+		return nil
+	}
+	if _, err := s.userRepo.UpdateFields(txCtx, user, "Tier"); err != nil {
+		return dbError(err)
+	}
+	_, err = s.tierRepo.SaveChange(txCtx, change)
+	return dbError(err)
+}
+
+// saveEvents 在事务内把订单事件及对应的消费总额变化事件写入发件箱（未配置发件箱时跳过）
+// 消费总额没有变化时只写入订单事件
+func (s *OrderAppService) saveEvents(txCtx context.Context, eventType string, order *models.Order, delta models.Money) error {
+	if s.outboxRepo == nil {
+		return nil
+	}
+
+	orderMsg, err := models.NewOutboxMessage(models.AggregateOrder, order.OrderID, eventType, models.OrderEvent{
+		OrderID:    order.OrderID,
+		UserID:     order.UserID,
+		Amount:     order.Amount,
+		Currency:   order.Currency,
+		BaseAmount: order.BaseAmount,
+		Status:     order.Status,
 	})
+	if err != nil {
+		return err
+	}
+	msgs := []*models.OutboxMessage{orderMsg}
+	if !delta.IsZero() {
+		userMsg, err := models.NewOutboxMessage(models.AggregateUser, order.UserID, models.EventConsumptionChanged,
+			models.ConsumptionChangedEvent{
+				UserID:  order.UserID,
+				Delta:   delta,
+				OrderID: order.OrderID,
+			})
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, userMsg)
+	}
+
+	for _, msg := range msgs {
+		if _, err := s.outboxRepo.Save(txCtx, msg); err != nil {
+			return dbError(err)
+		}
+	}
+	return nil
 }
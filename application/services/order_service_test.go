@@ -1,8 +1,10 @@
 package services_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
@@ -25,40 +27,35 @@ func TestCreateOrder_Success(t *testing.T) {
 
 	t.Run("成功创建订单", func(t *testing.T) {
 		// 初始化用户（消费总额200）
-		user := &models.User{ID: 3, TotalConsumption: 200}
-		orderAmount := 500.0
-
-		// 预期用户状态更新
-		expectedUser := &models.User{
-			ID:               3,
-			TotalConsumption: 200 + orderAmount, // 700
-		}
+		user := &models.User{ID: 3, TotalConsumption: models.MustParseMoney("200")}
+		orderAmount := models.MustParseMoney("500")
 
 		// 模拟仓储调用
-		mockUserRepo.EXPECT().FindByID(uint64(3)).Return(user, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(user, nil)
 
 		// 模拟事务管理器
-		mockTxManager.EXPECT().Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
-				// 验证用户保存
-				mockUserRepo.EXPECT().UpdateTotalConsumption(expectedUser).Return(int8(1), nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				// 验证消费总额按增量原子更新
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), orderAmount).Return(int8(1), nil)
 
 				// 验证订单生成
-				mockOrderRepo.EXPECT().Save(gomock.Any()).
-					Do(func(order *models.Order) {
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, order *models.Order) {
 						assert.Equal(t, user.ID, order.UserID)
 						assert.Equal(t, orderAmount, order.Amount)
-						assert.True(t, order.IsValid)
+						assert.Equal(t, models.OrderPending, order.Status)
 					}).Return(uint64(1001), nil)
-				return fn()
+				return fn(ctx)
 			})
 
 		// 执行测试
-		err := service.CreateOrder(services.CreateOrderCommand{
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
 			UserID: 3,
 			Amount: orderAmount,
 		})
 		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), orderID)
 	})
 }
 
@@ -77,18 +74,54 @@ func TestCreateOrder_UserNotFound(t *testing.T) {
 
 	t.Run("用户不存在时报错", func(t *testing.T) {
 		mockUserRepo.EXPECT().
-			FindByID(uint64(999)).
+			FindByID(gomock.Any(), uint64(999)).
 			Return(nil, repositories.ErrorNotFound).
 			Times(1)
 
-		err := service.CreateOrder(services.CreateOrderCommand{
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
 			UserID: 999,
-			Amount: 100,
+			Amount: models.MustParseMoney("100"),
 		})
 		assert.ErrorContains(t, err, "Not Found")
 	})
 }
 
+func TestCreateOrder_UserNotActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
+
+	t.Run("已停用的用户不能下单", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).
+			Return(&models.User{ID: 3, Status: models.UserSuspended}, nil)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("100"),
+		})
+		assert.ErrorIs(t, err, models.ErrUserNotActive)
+	})
+
+	t.Run("已注销的用户默认查询不到", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(4)).
+			DoAndReturn(func(ctx context.Context, _ uint64) (*models.User, error) {
+				assert.False(t, repositories.IncludesDeleted(ctx))
+				return nil, repositories.ErrorNotFound
+			})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 4,
+			Amount: models.MustParseMoney("100"),
+		})
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+}
+
 func TestCreateOrder_InvalidAmount(t *testing.T) {
 	// 初始化mock控制器
 	ctrl := gomock.NewController(t)
@@ -103,16 +136,16 @@ func TestCreateOrder_InvalidAmount(t *testing.T) {
 	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
 
 	t.Run("订单创建失败", func(t *testing.T) {
-		user := &models.User{ID: 1, TotalConsumption: 500}
+		user := &models.User{ID: 1, TotalConsumption: models.MustParseMoney("500")}
 		mockUserRepo.EXPECT().
-			FindByID(uint64(1)).
+			FindByID(gomock.Any(), uint64(1)).
 			Return(user, nil)
 
-		err := service.CreateOrder(services.CreateOrderCommand{
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
 			UserID: 1,
-			Amount: -50,
+			Amount: models.MustParseMoney("-50"),
 		})
-		assert.ErrorContains(t, err, "订单创建失败")
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
 	})
 }
 
@@ -130,18 +163,18 @@ func TestCreateOrder_RollbackTx(t *testing.T) {
 	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
 
 	t.Run("用户保存失败触发回滚", func(t *testing.T) {
-		user := &models.User{ID: 2, TotalConsumption: 1000}
-		// order := &models.Order{UserID: 2, Amount: 300}
+		user := &models.User{ID: 2, TotalConsumption: models.MustParseMoney("1000")}
+		// order := &models.Order{UserID: 2, Amount: models.MustParseMoney("300")}
 
-		mockUserRepo.EXPECT().FindByID(uint64(2)).Return(user, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2)).Return(user, nil)
 
-		mockTxManager.EXPECT().Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
-				mockUserRepo.EXPECT().UpdateTotalConsumption(user).Return(int8(1), errors.New("db error"))
-				return fn()
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2), models.MustParseMoney("300")).Return(int8(1), errors.New("db error"))
+				return fn(ctx)
 			})
 
-		err := service.CreateOrder(services.CreateOrderCommand{UserID: 2, Amount: 300})
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 2, Amount: models.MustParseMoney("300")})
 		assert.ErrorContains(t, err, "db error")
 	})
 }
@@ -160,19 +193,20 @@ func TestCreateOrder_ErrorUpdateUsersTable(t *testing.T) {
 	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
 
 	t.Run("users表更新行数错误", func(t *testing.T) {
-		user := &models.User{ID: 2, TotalConsumption: 1000}
-		// order := &models.Order{UserID: 2, Amount: 300}
+		user := &models.User{ID: 2, TotalConsumption: models.MustParseMoney("1000")}
+		// order := &models.Order{UserID: 2, Amount: models.MustParseMoney("300")}
 
-		mockUserRepo.EXPECT().FindByID(uint64(2)).Return(user, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2)).Return(user, nil)
 
-		mockTxManager.EXPECT().Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
-				mockUserRepo.EXPECT().UpdateTotalConsumption(user).Return(int8(2), nil)
-				return fn()
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2), models.MustParseMoney("300")).Return(int8(2), nil)
+				return fn(ctx)
 			})
 
-		err := service.CreateOrder(services.CreateOrderCommand{UserID: 2, Amount: 300})
-		assert.ErrorContains(t, err, "users表更新行数错误")
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 2, Amount: models.MustParseMoney("300")})
+		assert.ErrorIs(t, err, models.ErrInternal)
+		assert.Equal(t, models.CategoryInternal, models.CategoryOf(err))
 	})
 }
 
@@ -190,19 +224,19 @@ func TestCreateOrder_RollbackTxOrder(t *testing.T) {
 	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
 
 	t.Run("orders表插入错误导致回滚", func(t *testing.T) {
-		user := &models.User{ID: 2, TotalConsumption: 1000}
-		// order := &models.Order{UserID: 2, Amount: 300}
+		user := &models.User{ID: 2, TotalConsumption: models.MustParseMoney("1000")}
+		// order := &models.Order{UserID: 2, Amount: models.MustParseMoney("300")}
 
-		mockUserRepo.EXPECT().FindByID(uint64(2)).Return(user, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2)).Return(user, nil)
 
-		mockTxManager.EXPECT().Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
-				mockUserRepo.EXPECT().UpdateTotalConsumption(user).Return(int8(1), nil)
-				mockOrderRepo.EXPECT().Save(gomock.Any()).Return(uint64(1001), errors.New("db error"))
-				return fn()
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2), models.MustParseMoney("300")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1001), errors.New("db error"))
+				return fn(ctx)
 			})
 
-		err := service.CreateOrder(services.CreateOrderCommand{UserID: 2, Amount: 300})
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 2, Amount: models.MustParseMoney("300")})
 		assert.ErrorContains(t, err, "db error")
 	})
 }
@@ -223,42 +257,44 @@ func TestInvalidAmount_Success(t *testing.T) {
 	t.Run("成功失效订单并扣减消费", func(t *testing.T) {
 		// 设置订单预期
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1001)).
+			FindByID(gomock.Any(), uint64(1001)).
 			Return(&models.Order{
-				OrderID: 1001,
-				UserID:  2001,
-				Amount:  500.0,
-				IsValid: true,
+				OrderID:    1001,
+				UserID:     2001,
+				Amount:     models.MustParseMoney("500"),
+				BaseAmount: models.MustParseMoney("500"),
+				Status:     models.OrderPaid,
 			}, nil)
 
 		// 设置用户预期
 		mockUserRepo.EXPECT().
-			FindByID(uint64(2001)).
+			FindByID(gomock.Any(), uint64(2001)).
 			Return(&models.User{
 				ID:               2001,
-				TotalConsumption: 1500.0,
+				TotalConsumption: models.MustParseMoney("1500"),
 			}, nil)
 
 		// 事务管理器预期
 		mockTxManager.EXPECT().
-			Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
 				// 验证事务内操作
 				mockOrderRepo.EXPECT().
-					UpdateValidity(uint64(1001), false).
+					UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).
+					Do(func(_ context.Context, order *models.Order, _ models.OrderStatus) {
+						assert.Equal(t, uint64(1001), order.OrderID)
+						assert.Equal(t, models.OrderCancelled, order.Status)
+					}).
 					Return(int8(1), nil)
 
 				mockUserRepo.EXPECT().
-					UpdateTotalConsumption(&models.User{
-						ID:               2001,
-						TotalConsumption: 1000.0, // 500元扣减
-					}).
+					AddTotalConsumption(gomock.Any(), uint64(2001), models.MustParseMoney("-500")). // 500元扣减
 					Return(int8(1), nil)
 
-				return fn()
+				return fn(ctx)
 			})
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1001})
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1001})
 		assert.NoError(t, err)
 	})
 }
@@ -277,11 +313,13 @@ func TestInvalidAmount_OrderNotExist(t *testing.T) {
 
 	t.Run("订单不存在时报错", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(999)).
-			Return(nil, repositories.ErrorInvalid)
+			FindByID(gomock.Any(), uint64(999)).
+			Return(nil, repositories.ErrorNotFound)
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 999})
-		assert.ErrorContains(t, err, "Invalid")
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 999})
+		assert.ErrorIs(t, err, models.ErrOrderNotFound)
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+		assert.Equal(t, models.CategoryNotFound, models.CategoryOf(err))
 	})
 }
 
@@ -299,15 +337,27 @@ func TestInvalidAmount_OrderInvalid(t *testing.T) {
 
 	t.Run("重复失效订单时报错", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1002)).
+			FindByID(gomock.Any(), uint64(1002)).
 			Return(&models.Order{
 				OrderID: 1002,
-				IsValid: false,
+				Status:  models.OrderCancelled,
 			}, nil)
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1002})
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1002})
 		assert.ErrorContains(t, err, "订单已失效")
 	})
+
+	t.Run("已发货或已完成的订单不能失效", func(t *testing.T) {
+		for _, status := range []models.OrderStatus{models.OrderShipped, models.OrderCompleted} {
+			mockOrderRepo.EXPECT().
+				FindByID(gomock.Any(), uint64(1003)).
+				Return(&models.Order{OrderID: 1003, UserID: 1, Amount: models.MustParseMoney("100"),
+					BaseAmount: models.MustParseMoney("100"), Status: status}, nil)
+
+			err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1003})
+			assert.ErrorIs(t, err, models.ErrInvalidTransition, status)
+		}
+	})
 }
 
 func TestInvalidAmount_UserNotExist(t *testing.T) {
@@ -324,18 +374,18 @@ func TestInvalidAmount_UserNotExist(t *testing.T) {
 
 	t.Run("订单关联用户不存在时报错", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1003)).
+			FindByID(gomock.Any(), uint64(1003)).
 			Return(&models.Order{
 				OrderID: 1003,
 				UserID:  3001,
-				IsValid: true,
+				Status:  models.OrderPaid,
 			}, nil)
 
 		mockUserRepo.EXPECT().
-			FindByID(uint64(3001)).
+			FindByID(gomock.Any(), uint64(3001)).
 			Return(nil, repositories.ErrorNotFound)
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1003})
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1003})
 		assert.ErrorContains(t, err, "Not Found")
 	})
 }
@@ -354,22 +404,23 @@ func TestInvalidAmount_AmountDesFailed(t *testing.T) {
 
 	t.Run("用户余额不足时报错", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1004)).
+			FindByID(gomock.Any(), uint64(1004)).
 			Return(&models.Order{
-				OrderID: 1004,
-				UserID:  2002,
-				Amount:  1000.0,
-				IsValid: true,
+				OrderID:    1004,
+				UserID:     2002,
+				Amount:     models.MustParseMoney("1000"),
+				BaseAmount: models.MustParseMoney("1000"),
+				Status:     models.OrderPaid,
 			}, nil)
 
 		mockUserRepo.EXPECT().
-			FindByID(uint64(2002)).
+			FindByID(gomock.Any(), uint64(2002)).
 			Return(&models.User{
 				ID:               2002,
-				TotalConsumption: 500.0,
+				TotalConsumption: models.MustParseMoney("500"),
 			}, nil)
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1004})
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1004})
 		assert.ErrorContains(t, err, "消费总额不足")
 	})
 }
@@ -388,33 +439,34 @@ func TestInvalidAmount_TxRollBack(t *testing.T) {
 
 	t.Run("事务内操作失败时回滚", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1005)).
+			FindByID(gomock.Any(), uint64(1005)).
 			Return(&models.Order{
-				OrderID: 1005,
-				UserID:  2003,
-				Amount:  200.0,
-				IsValid: true,
+				OrderID:    1005,
+				UserID:     2003,
+				Amount:     models.MustParseMoney("200"),
+				BaseAmount: models.MustParseMoney("200"),
+				Status:     models.OrderPaid,
 			}, nil)
 
 		mockUserRepo.EXPECT().
-			FindByID(uint64(2003)).
+			FindByID(gomock.Any(), uint64(2003)).
 			Return(&models.User{
 				ID:               2003,
-				TotalConsumption: 1000.0,
+				TotalConsumption: models.MustParseMoney("1000"),
 			}, nil)
 
 		mockTxManager.EXPECT().
-			Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
 				mockOrderRepo.EXPECT().
-					UpdateValidity(uint64(1005), false).
+					UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(int8(0), errors.New("数据库连接失败"))
 
 				// 用户保存不会被调用
-				return fn()
+				return fn(ctx)
 			})
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1005})
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1005})
 		assert.ErrorContains(t, err, "数据库连接失败")
 	})
 }
@@ -433,34 +485,35 @@ func TestInvalidAmount_ErrorUpdateOrder(t *testing.T) {
 
 	t.Run("orders表更新行数错误", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1005)).
+			FindByID(gomock.Any(), uint64(1005)).
 			Return(&models.Order{
-				OrderID: 1005,
-				UserID:  2003,
-				Amount:  200.0,
-				IsValid: true,
+				OrderID:    1005,
+				UserID:     2003,
+				Amount:     models.MustParseMoney("200"),
+				BaseAmount: models.MustParseMoney("200"),
+				Status:     models.OrderPaid,
 			}, nil)
 
 		mockUserRepo.EXPECT().
-			FindByID(uint64(2003)).
+			FindByID(gomock.Any(), uint64(2003)).
 			Return(&models.User{
 				ID:               2003,
-				TotalConsumption: 1000.0,
+				TotalConsumption: models.MustParseMoney("1000"),
 			}, nil)
 
 		mockTxManager.EXPECT().
-			Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
 				mockOrderRepo.EXPECT().
-					UpdateValidity(uint64(1005), false).
+					UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(int8(2), nil)
 
 				// 用户保存不会被调用
-				return fn()
+				return fn(ctx)
 			})
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1005})
-		assert.ErrorContains(t, err, "orders表更新行数错误")
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1005})
+		assert.ErrorIs(t, err, models.ErrInternal)
 	})
 }
 
@@ -478,35 +531,1006 @@ func TestInvalidAmount_ErrorUpdateUser(t *testing.T) {
 
 	t.Run("users表更新行数错误", func(t *testing.T) {
 		mockOrderRepo.EXPECT().
-			FindByID(uint64(1005)).
+			FindByID(gomock.Any(), uint64(1005)).
 			Return(&models.Order{
-				OrderID: 1005,
-				UserID:  2003,
-				Amount:  200.0,
-				IsValid: true,
+				OrderID:    1005,
+				UserID:     2003,
+				Amount:     models.MustParseMoney("200"),
+				BaseAmount: models.MustParseMoney("200"),
+				Status:     models.OrderPaid,
 			}, nil)
 
 		mockUserRepo.EXPECT().
-			FindByID(uint64(2003)).
+			FindByID(gomock.Any(), uint64(2003)).
 			Return(&models.User{
 				ID:               2003,
-				TotalConsumption: 1000.0,
+				TotalConsumption: models.MustParseMoney("1000"),
 			}, nil)
 
 		mockTxManager.EXPECT().
-			Transaction(gomock.Any()).
-			DoAndReturn(func(fn func() error) error {
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
 				mockOrderRepo.EXPECT().
-					UpdateValidity(uint64(1005), false).
+					UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(int8(1), nil)
 				mockUserRepo.EXPECT().
-					UpdateTotalConsumption(gomock.Any()).
+					AddTotalConsumption(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(int8(2), nil)
 
-				return fn()
+				return fn(ctx)
+			})
+
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1005})
+		assert.ErrorIs(t, err, models.ErrInternal)
+	})
+}
+
+func TestInvalidAmount_VersionConflictRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 初始化mock对象
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	// 创建服务实例
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
+
+	t.Run("版本冲突时重新读取并重试", func(t *testing.T) {
+		// 第一次读到版本1，第二次读到被并发修改后的版本2
+		gomock.InOrder(
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1006)).
+				Return(&models.Order{OrderID: 1006, UserID: 2004, Amount: models.MustParseMoney("100"), BaseAmount: models.MustParseMoney("100"), Status: models.OrderPaid, Version: 1}, nil),
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1006)).
+				Return(&models.Order{OrderID: 1006, UserID: 2004, Amount: models.MustParseMoney("100"), BaseAmount: models.MustParseMoney("100"), Status: models.OrderPaid, Version: 2}, nil),
+		)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2004)).
+			Return(&models.User{ID: 2004, TotalConsumption: models.MustParseMoney("1000")}, nil).
+			Times(2)
+
+		var versions []uint64
+		mockTxManager.EXPECT().
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).
+			Times(2)
+		gomock.InOrder(
+			mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, order *models.Order, _ models.OrderStatus) {
+					versions = append(versions, order.Version)
+				}).
+				Return(int8(0), repositories.ErrorVersionConflict),
+			mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+				Do(func(_ context.Context, order *models.Order, _ models.OrderStatus) {
+					versions = append(versions, order.Version)
+				}).
+				Return(int8(1), nil),
+		)
+		mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2004), models.MustParseMoney("-100")).
+			Return(int8(1), nil)
+
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1006})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{1, 2}, versions)
+	})
+
+	t.Run("多次冲突后返回冲突错误", func(t *testing.T) {
+		// 每次重新读取都返回新的订单对象
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1007)).
+			DoAndReturn(func(_ context.Context, _ uint64) (*models.Order, error) {
+				return &models.Order{OrderID: 1007, UserID: 2005, Amount: models.MustParseMoney("100"), BaseAmount: models.MustParseMoney("100"), Status: models.OrderPaid}, nil
+			}).
+			AnyTimes()
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2005)).
+			Return(&models.User{ID: 2005, TotalConsumption: models.MustParseMoney("1000")}, nil).
+			AnyTimes()
+		mockTxManager.EXPECT().
+			Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).
+			AnyTimes()
+		mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(int8(0), repositories.ErrorVersionConflict).
+			AnyTimes()
+
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1007})
+		assert.ErrorIs(t, err, repositories.ErrorVersionConflict)
+	})
+}
+
+func TestCreateOrder_WithOutbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithOutbox(mockOutboxRepo))
+
+	t.Run("订单事件与订单在同一事务中写入发件箱", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, order *models.Order) (uint64, error) {
+						order.OrderID = 1001
+						return order.OrderID, nil
+					})
+				gomock.InOrder(
+					mockOutboxRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, msg *models.OutboxMessage) {
+							assert.Equal(t, models.AggregateOrder, msg.AggregateType)
+							assert.Equal(t, uint64(1001), msg.AggregateID)
+							assert.Equal(t, models.EventOrderCreated, msg.EventType)
+							assert.JSONEq(t, `{"order_id":1001,"user_id":3,"amount":500,"currency":"CNY","base_amount":500,"status":"pending"}`, msg.Payload)
+						}).Return(uint64(1), nil),
+					mockOutboxRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, msg *models.OutboxMessage) {
+							assert.Equal(t, models.AggregateUser, msg.AggregateType)
+							assert.Equal(t, uint64(3), msg.AggregateID)
+							assert.Equal(t, models.EventConsumptionChanged, msg.EventType)
+						}).Return(uint64(2), nil),
+				)
+				return fn(ctx)
+			})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500")})
+		assert.NoError(t, err)
+	})
+
+	t.Run("写入发件箱失败时事务回滚", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1002), nil)
+				mockOutboxRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), errors.New("db error"))
+				return fn(ctx)
+			})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500")})
+		assert.ErrorIs(t, err, models.ErrInternal)
+	})
+}
+
+func TestCreateOrder_IdempotencyKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockIdempotencyRepo := mocks.NewMockIdempotencyRepository(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager,
+		services.WithIdempotency(mockIdempotencyRepo))
+
+	cmd := services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500"), IdempotencyKey: "batch-20250521-0001"}
+
+	t.Run("首次请求创建订单并记录幂等键", func(t *testing.T) {
+		mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), cmd.IdempotencyKey).Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+
+		var savedHash string
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1001), nil)
+				mockIdempotencyRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, record *models.IdempotencyRecord) {
+						assert.Equal(t, cmd.IdempotencyKey, record.Key)
+						assert.Equal(t, uint64(1001), record.ResourceID)
+						assert.NotEmpty(t, record.RequestHash)
+						savedHash = record.RequestHash
+					}).Return(nil)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), orderID)
+
+		t.Run("重复请求返回原订单ID", func(t *testing.T) {
+			mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), cmd.IdempotencyKey).
+				Return(&models.IdempotencyRecord{Key: cmd.IdempotencyKey, RequestHash: savedHash, ResourceID: 1001}, nil)
+
+			orderID, err := service.CreateOrder(context.Background(), cmd)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1001), orderID)
+		})
+
+		t.Run("相同幂等键不同内容被拒绝", func(t *testing.T) {
+			mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), cmd.IdempotencyKey).
+				Return(&models.IdempotencyRecord{Key: cmd.IdempotencyKey, RequestHash: savedHash, ResourceID: 1001}, nil)
+
+			other := cmd
+			other.Amount = models.MustParseMoney("600")
+			_, err := service.CreateOrder(context.Background(), other)
+			assert.ErrorIs(t, err, services.ErrIdempotencyKeyConflict)
+		})
+	})
+
+	t.Run("并发的相同请求已提交时返回其订单ID", func(t *testing.T) {
+		key := "batch-20250521-0002"
+		concurrent := services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500"), IdempotencyKey: key}
+		var savedHash string
+
+		mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), key).Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1003), nil)
+				mockIdempotencyRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, record *models.IdempotencyRecord) {
+						savedHash = record.RequestHash
+					}).Return(repositories.ErrorDuplicate)
+				return fn(ctx)
+			})
+		mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), key).
+			DoAndReturn(func(_ context.Context, _ string) (*models.IdempotencyRecord, error) {
+				return &models.IdempotencyRecord{Key: key, RequestHash: savedHash, ResourceID: 1002}, nil
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), concurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1002), orderID)
+	})
+
+	t.Run("重复不是来自幂等键时返回错误", func(t *testing.T) {
+		key := "batch-20250521-0003"
+		other := services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500"), IdempotencyKey: key}
+
+		mockIdempotencyRepo.EXPECT().FindByKey(gomock.Any(), key).Return(nil, repositories.ErrorNotFound).Times(2)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), repositories.ErrorDuplicate)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), other)
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+		assert.Zero(t, orderID)
+	})
+}
+
+func TestCreateOrder_ForeignCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockRateRepo := mocks.NewMockExchangeRateRepository(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager,
+		services.WithExchangeRates(mockRateRepo))
+
+	t.Run("按生效汇率折算消费总额并保存原始金额", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockRateRepo.EXPECT().FindEffective(gomock.Any(), models.USD, gomock.Any()).
+			Return(&models.ExchangeRate{Currency: models.USD, Rate: models.MustParseRate("7.1234")}, nil)
+
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				// 消费总额以基准币种累加：100 USD * 7.1234 = 712.34 CNY
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("712.34")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, order *models.Order) {
+						assert.Equal(t, models.USD, order.Currency)
+						assert.Equal(t, models.MustParseMoney("100"), order.Amount)
+						assert.Equal(t, models.MustParseRate("7.1234"), order.ExchangeRate)
+						assert.Equal(t, models.MustParseMoney("712.34"), order.BaseAmount)
+					}).Return(uint64(1001), nil)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID:   3,
+			Amount:   models.MustParseMoney("100"),
+			Currency: "usd",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), orderID)
+	})
+
+	t.Run("没有生效的汇率时报错", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockRateRepo.EXPECT().FindEffective(gomock.Any(), models.JPY, gomock.Any()).Return(nil, repositories.ErrorNotFound)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID:   3,
+			Amount:   models.MustParseMoney("1000"),
+			Currency: models.JPY,
+		})
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	t.Run("不支持的币种", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID:   3,
+			Amount:   models.MustParseMoney("1000"),
+			Currency: "EUR",
+		})
+		assert.ErrorIs(t, err, models.ErrUnsupportedCurrency)
+	})
+}
+
+func TestChangeOrderStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager)
+
+	newOrder := func(status models.OrderStatus) *models.Order {
+		return &models.Order{OrderID: 1008, UserID: 2006, Amount: models.MustParseMoney("300"),
+			BaseAmount: models.MustParseMoney("300"), Status: status, Version: 3}
+	}
+
+	t.Run("支付不影响消费总额", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1008)).Return(newOrder(models.OrderPending), nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2006)).
+			Return(&models.User{ID: 2006, TotalConsumption: models.MustParseMoney("300")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPending).
+					Do(func(_ context.Context, order *models.Order, _ models.OrderStatus) {
+						assert.Equal(t, models.OrderPaid, order.Status)
+						assert.Equal(t, uint64(3), order.Version)
+					}).Return(int8(1), nil)
+				// 消费总额不会被更新
+				return fn(ctx)
+			})
+
+		err := service.ChangeOrderStatus(context.Background(), services.ChangeOrderStatusCommand{OrderID: 1008, Status: models.OrderPaid})
+		assert.NoError(t, err)
+	})
+
+	t.Run("取消扣除消费总额", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1008)).Return(newOrder(models.OrderPaid), nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2006)).
+			Return(&models.User{ID: 2006, TotalConsumption: models.MustParseMoney("300")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil)
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2006), models.MustParseMoney("-300")).Return(int8(1), nil)
+				return fn(ctx)
+			})
+
+		err := service.ChangeOrderStatus(context.Background(), services.ChangeOrderStatusCommand{OrderID: 1008, Status: models.OrderCancelled})
+		assert.NoError(t, err)
+	})
+
+	t.Run("不允许的状态变更", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1008)).Return(newOrder(models.OrderCancelled), nil)
+
+		err := service.ChangeOrderStatus(context.Background(), services.ChangeOrderStatusCommand{OrderID: 1008, Status: models.OrderShipped})
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+	})
+}
+
+func TestRefundOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithRefunds(mockRefundRepo))
+
+	newOrder := func(refunded string, version uint64) *models.Order {
+		return &models.Order{OrderID: 1009, UserID: 2007, Amount: models.MustParseMoney("300"), ExchangeRate: models.RateOne,
+			BaseAmount: models.MustParseMoney("300"), RefundedAmount: models.MustParseMoney(refunded),
+			RefundedBaseAmount: models.MustParseMoney(refunded), Status: models.OrderPaid, Version: version}
+	}
+
+	t.Run("部分退款", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("0", 1), nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2007)).
+			Return(&models.User{ID: 2007, TotalConsumption: models.MustParseMoney("300")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateRefund(gomock.Any(), gomock.Any(), models.OrderPaid).
+					Do(func(_ context.Context, order *models.Order, _ models.OrderStatus) {
+						assert.Equal(t, models.MustParseMoney("100"), order.RefundedAmount)
+						assert.Equal(t, models.OrderPaid, order.Status)
+					}).Return(int8(1), nil)
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2007), models.MustParseMoney("-100")).Return(int8(1), nil)
+				mockRefundRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, refund *models.Refund) {
+						assert.Equal(t, uint64(1009), refund.OrderID)
+						assert.Equal(t, models.MustParseMoney("100"), refund.Amount)
+						assert.Equal(t, "商品破损", refund.Reason)
+					}).Return(uint64(1), nil)
+				return fn(ctx)
+			})
+
+		refundID, err := service.RefundOrder(context.Background(), services.RefundOrderCommand{
+			OrderID: 1009, Amount: models.MustParseMoney("100"), Reason: "商品破损"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), refundID)
+	})
+
+	t.Run("超过剩余可退金额", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("100", 2), nil)
+
+		_, err := service.RefundOrder(context.Background(), services.RefundOrderCommand{
+			OrderID: 1009, Amount: models.MustParseMoney("200.01"), Reason: "退货"})
+		assert.ErrorIs(t, err, models.ErrRefundExceedsPaid)
+	})
+
+	t.Run("并发退款冲突时重新读取后校验", func(t *testing.T) {
+		gomock.InOrder(
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("100", 2), nil),
+			// 重新读取时另一笔退款已提交
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("250", 3), nil),
+		)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2007)).
+			Return(&models.User{ID: 2007, TotalConsumption: models.MustParseMoney("200")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateRefund(gomock.Any(), gomock.Any(), models.OrderPaid).
+					Return(int8(0), repositories.ErrorVersionConflict)
+				return fn(ctx)
+			})
+
+		_, err := service.RefundOrder(context.Background(), services.RefundOrderCommand{
+			OrderID: 1009, Amount: models.MustParseMoney("100"), Reason: "退货"})
+		assert.ErrorIs(t, err, models.ErrRefundExceedsPaid)
+	})
+
+	t.Run("事务重试时重新插入退款记录", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("0", 1), nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2007)).
+			Return(&models.User{ID: 2007, TotalConsumption: models.MustParseMoney("300")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateRefund(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil).Times(2)
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2007), models.MustParseMoney("-100")).
+					Return(int8(1), nil).Times(2)
+				gomock.InOrder(
+					mockRefundRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(5), nil),
+					mockRefundRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, refund *models.Refund) {
+							assert.Zero(t, refund.ID)
+						}).Return(uint64(6), nil),
+				)
+				// 第一次尝试提交时死锁，事务回滚后重试
+				assert.NoError(t, fn(ctx))
+				return fn(ctx)
+			})
+
+		refundID, err := service.RefundOrder(context.Background(), services.RefundOrderCommand{
+			OrderID: 1009, Amount: models.MustParseMoney("100"), Reason: "退货"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(6), refundID)
+	})
+
+	t.Run("查询退款记录", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1009)).Return(newOrder("100", 2), nil)
+		mockRefundRepo.EXPECT().FindByOrderID(gomock.Any(), uint64(1009)).
+			Return([]*models.Refund{{ID: 1, OrderID: 1009, Amount: models.MustParseMoney("100"), Reason: "商品破损"}}, nil)
+
+		refunds, err := service.RefundHistory(context.Background(), 1009)
+		assert.NoError(t, err)
+		assert.Len(t, refunds, 1)
+	})
+}
+
+func TestCreateOrder_WithItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockProductRepo := mocks.NewMockProductRepository(ctrl)
+	mockItemRepo := mocks.NewMockOrderItemRepository(ctrl)
+
+	service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager,
+		services.WithCatalog(mockProductRepo, mockItemRepo))
+
+	cup := &models.Product{ID: 1, SKU: "SKU-001", Name: "水杯", UnitPrice: models.MustParseMoney("19.9"), Currency: models.CNY, Stock: 10}
+	pen := &models.Product{ID: 2, SKU: "SKU-002", Name: "钢笔", UnitPrice: models.MustParseMoney("35"), Currency: models.CNY, Stock: 2}
+
+	t.Run("按明细计算金额并与订单在同一事务中写入", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-001").Return(cup, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-002").Return(pen, nil)
+
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				// 19.9 * 3 + 35 * 2 = 129.7
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("129.7")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, order *models.Order) {
+						assert.Equal(t, models.MustParseMoney("129.7"), order.Amount)
+					}).Return(uint64(1010), nil)
+				mockItemRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, items []models.OrderItem) {
+						if assert.Len(t, items, 2) {
+							assert.Equal(t, uint64(1010), items[0].OrderID)
+							assert.Equal(t, "SKU-001", items[0].SKU)
+							assert.Equal(t, models.MustParseMoney("19.9"), items[0].UnitPrice)
+							assert.Equal(t, 3, items[0].Quantity)
+							assert.Equal(t, models.MustParseMoney("59.7"), items[0].Subtotal)
+							assert.Equal(t, uint64(1010), items[1].OrderID)
+						}
+					}).Return(nil)
+				// 在同一事务中按商品ID顺序扣减库存
+				gomock.InOrder(
+					mockProductRepo.EXPECT().DecrementStock(gomock.Any(), uint64(1), 3).Return(nil),
+					mockProductRepo.EXPECT().DecrementStock(gomock.Any(), uint64(2), 2).Return(nil),
+				)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Items:  []services.OrderItemCommand{{SKU: "SKU-001", Quantity: 3}, {SKU: "SKU-002", Quantity: 2}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1010), orderID)
+	})
+
+	t.Run("事务重试时重新插入订单与明细", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-001").Return(cup, nil)
+
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("19.9")).
+					Return(int8(1), nil).Times(2)
+				gomock.InOrder(
+					mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1011), nil),
+					mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, order *models.Order) {
+							assert.Zero(t, order.OrderID)
+						}).Return(uint64(1012), nil),
+				)
+				gomock.InOrder(
+					mockItemRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, items []models.OrderItem) {
+							items[0].ID = 21
+						}).Return(nil),
+					mockItemRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, items []models.OrderItem) {
+							assert.Zero(t, items[0].ID)
+							assert.Equal(t, uint64(1012), items[0].OrderID)
+						}).Return(nil),
+				)
+				gomock.InOrder(
+					mockProductRepo.EXPECT().DecrementStock(gomock.Any(), uint64(1), 1).
+						Return(errors.New("Error 1213: Deadlock found when trying to get lock")),
+					mockProductRepo.EXPECT().DecrementStock(gomock.Any(), uint64(1), 1).Return(nil),
+				)
+				// 第一次尝试死锁回滚，事务管理器重试
+				assert.Error(t, fn(ctx))
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Items:  []services.OrderItemCommand{{SKU: "SKU-001", Quantity: 1}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1012), orderID)
+	})
+
+	t.Run("下单前检查库存", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-002").Return(pen, nil)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Items:  []services.OrderItemCommand{{SKU: "SKU-002", Quantity: 3}},
+		})
+		var outOfStock *models.OutOfStockError
+		if assert.ErrorAs(t, err, &outOfStock) {
+			assert.Equal(t, "SKU-002", outOfStock.SKU)
+			assert.Equal(t, 3, outOfStock.Requested)
+			assert.Equal(t, 2, outOfStock.Available)
+		}
+	})
+
+	t.Run("并发下单导致扣减库存失败时整体回滚", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-002").Return(pen, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("70")).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1011), nil)
+				mockItemRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				mockProductRepo.EXPECT().DecrementStock(gomock.Any(), uint64(2), 2).
+					Return(&models.OutOfStockError{SKU: "SKU-002", Requested: 2, Available: 1})
+				return fn(ctx)
 			})
 
-		err := service.InvalidateOrder(services.InvalidateOrderCommand{OrderID: 1005})
-		assert.ErrorContains(t, err, "users表更新行数错误")
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Items:  []services.OrderItemCommand{{SKU: "SKU-002", Quantity: 2}},
+		})
+		assert.ErrorIs(t, err, models.ErrOutOfStock)
+	})
+
+	t.Run("已注销用户的订单仍可取消", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1020)).Return(&models.Order{
+			OrderID: 1020, UserID: 5, Amount: models.MustParseMoney("10"), BaseAmount: models.MustParseMoney("10"),
+			Status: models.OrderPaid,
+		}, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(5)).
+			DoAndReturn(func(ctx context.Context, _ uint64) (*models.User, error) {
+				assert.True(t, repositories.IncludesDeleted(ctx))
+				return &models.User{ID: 5, Status: models.UserDeleted, TotalConsumption: models.MustParseMoney("10")}, nil
+			})
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil)
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(5), models.MustParseMoney("-10")).
+					DoAndReturn(func(ctx context.Context, _ uint64, _ models.Money) (int8, error) {
+						assert.True(t, repositories.IncludesDeleted(ctx))
+						return int8(1), nil
+					})
+				return fn(ctx)
+			})
+
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1020})
+		assert.NoError(t, err)
+	})
+
+	t.Run("取消订单归还库存", func(t *testing.T) {
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1010)).Return(&models.Order{
+			OrderID: 1010, UserID: 3, Amount: models.MustParseMoney("129.7"), BaseAmount: models.MustParseMoney("129.7"),
+			Status: models.OrderPaid,
+			Items: []models.OrderItem{
+				{OrderID: 1010, ProductID: 1, SKU: "SKU-001", Quantity: 3},
+				{OrderID: 1010, ProductID: 2, SKU: "SKU-002", Quantity: 2},
+			},
+		}, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).
+			Return(&models.User{ID: 3, TotalConsumption: models.MustParseMoney("129.7")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil)
+				mockProductRepo.EXPECT().IncrementStock(gomock.Any(), uint64(1), 3).Return(nil)
+				mockProductRepo.EXPECT().IncrementStock(gomock.Any(), uint64(2), 2).Return(nil)
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("-129.7")).Return(int8(1), nil)
+				return fn(ctx)
+			})
+
+		err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1010})
+		assert.NoError(t, err)
+	})
+
+	t.Run("商品不存在", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-404").Return(nil, repositories.ErrorNotFound)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Items:  []services.OrderItemCommand{{SKU: "SKU-404", Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	t.Run("同时指定金额与明细", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("100"),
+			Items:  []services.OrderItemCommand{{SKU: "SKU-001", Quantity: 1}},
+		})
+		assert.ErrorIs(t, err, models.ErrInvalidArgument)
+	})
+}
+
+func TestOrderTiers(t *testing.T) {
+	schedule := models.TierSchedule{
+		{Tier: "bronze", MinConsumption: models.MustParseMoney("0")},
+		{Tier: "silver", MinConsumption: models.MustParseMoney("1000")},
+	}
+
+	t.Run("下单使消费总额达到门槛时升级并记录", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		mockTierRepo := mocks.NewMockTierRepository(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithTiers(mockTierRepo))
+
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).
+			Return(&models.User{ID: 3, Tier: "bronze", TotalConsumption: models.MustParseMoney("800")}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				gomock.InOrder(
+					mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), models.MustParseMoney("500")).Return(int8(1), nil),
+					mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1001), nil),
+					mockTierRepo.EXPECT().FindThresholds(gomock.Any()).Return(schedule, nil),
+					// 加锁读取增量更新后的消费总额
+					mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint64(3), repositories.LockWait).
+						Return(&models.User{ID: 3, Tier: "bronze", TotalConsumption: models.MustParseMoney("1300"), Version: 4}, nil),
+					mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Tier").
+						Do(func(_ context.Context, user *models.User, _ ...string) {
+							assert.Equal(t, "silver", user.Tier)
+							assert.Equal(t, int64(4), int64(user.Version))
+						}).Return(int8(1), nil),
+					mockTierRepo.EXPECT().SaveChange(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, change *models.TierChange) {
+							assert.Equal(t, "bronze", change.FromTier)
+							assert.Equal(t, "silver", change.ToTier)
+							assert.Equal(t, uint64(1001), change.OrderID)
+						}).Return(uint64(1), nil),
+				)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("500"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), orderID)
+	})
+
+	t.Run("失效订单降级，等级未变化时不写入", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		mockTierRepo := mocks.NewMockTierRepository(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithTiers(mockTierRepo))
+
+		for _, c := range []struct {
+			total   string
+			changed bool
+		}{{"700", true}, {"1200", false}} {
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.Order{
+				OrderID:    1001,
+				UserID:     2001,
+				Amount:     models.MustParseMoney("500"),
+				BaseAmount: models.MustParseMoney("500"),
+				Status:     models.OrderPaid,
+			}, nil)
+			mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2001)).
+				Return(&models.User{ID: 2001, Tier: "silver", TotalConsumption: models.MustParseMoney("1200")}, nil)
+			mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil)
+					mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2001), models.MustParseMoney("-500")).Return(int8(1), nil)
+					mockTierRepo.EXPECT().FindThresholds(gomock.Any()).Return(schedule, nil)
+					mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint64(2001), repositories.LockWait).
+						Return(&models.User{ID: 2001, Tier: "silver", TotalConsumption: models.MustParseMoney(c.total)}, nil)
+					if c.changed {
+						mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Tier").Return(int8(1), nil)
+						mockTierRepo.EXPECT().SaveChange(gomock.Any(), gomock.Any()).
+							Do(func(_ context.Context, change *models.TierChange) {
+								assert.Equal(t, "silver", change.FromTier)
+								assert.Equal(t, "bronze", change.ToTier)
+							}).Return(uint64(2), nil)
+					}
+					return fn(ctx)
+				})
+
+			err := service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1001})
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestCreateOrder_SpendingLimits(t *testing.T) {
+	defaults := models.SpendingLimits{
+		MaxOrderAmount: models.MustParseMoney("1000"),
+		RollingCap:     models.MustParseMoney("3000"),
+	}
+
+	t.Run("单笔金额超过默认上限", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithSpendingLimits(defaults))
+
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), gomock.Any()).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().FindByUserIDSince(gomock.Any(), uint64(3), gomock.Any()).Return(nil, nil)
+				return fn(ctx) // 返回错误即回滚，不保存订单
+			})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("1500"),
+		})
+		var limitErr *models.LimitExceededError
+		if assert.True(t, errors.As(err, &limitErr)) {
+			assert.Equal(t, models.LimitSingleOrder, limitErr.Kind)
+		}
+		assert.True(t, errors.Is(err, models.ErrLimitExceeded))
+	})
+
+	t.Run("最近30天累计超过上限", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithSpendingLimits(defaults))
+
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), gomock.Any()).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().FindByUserIDSince(gomock.Any(), uint64(3), gomock.Any()).
+					Do(func(_ context.Context, _ uint64, since time.Time) {
+						assert.WithinDuration(t, time.Now().Add(-models.SpendingWindow), since, time.Minute)
+					}).
+					Return([]*models.Order{
+						{BaseAmount: models.MustParseMoney("1000"), Status: models.OrderPaid},
+						{BaseAmount: models.MustParseMoney("1600"), Status: models.OrderCompleted},
+						{BaseAmount: models.MustParseMoney("1000"), Status: models.OrderCancelled}, // 已取消的不计入
+					}, nil)
+				return fn(ctx)
+			})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("500"),
+		})
+		var limitErr *models.LimitExceededError
+		if assert.True(t, errors.As(err, &limitErr)) {
+			assert.Equal(t, models.LimitRolling30d, limitErr.Kind)
+			assert.Equal(t, models.MustParseMoney("2600"), limitErr.Current)
+		}
+	})
+
+	t.Run("用户单独设置的限额优先", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithSpendingLimits(defaults))
+
+		maxOrder := models.MustParseMoney("20000")
+		unlimited := models.Money{}
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).
+			Return(&models.User{ID: 3, MaxOrderAmount: &maxOrder, RollingSpendingCap: &unlimited}, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				// 滚动上限为0（不限制）时不查询最近的订单
+				mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), gomock.Any()).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1001), nil)
+				return fn(ctx)
+			})
+
+		orderID, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{
+			UserID: 3,
+			Amount: models.MustParseMoney("15000"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), orderID)
+	})
+}
+
+// expectTransactionWithAfterCommit 模拟事务：fn 成功时执行登记的提交后回调，失败时丢弃
+func expectTransactionWithAfterCommit(mockTxManager *mocks.MockTransactionManager, setup func()) {
+	var callbacks []func(context.Context)
+	mockTxManager.EXPECT().AfterCommit(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, fn func(context.Context)) {
+			callbacks = append(callbacks, fn)
+		}).AnyTimes()
+	mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			setup()
+			if err := fn(ctx); err != nil {
+				return err
+			}
+			for _, callback := range callbacks {
+				callback(ctx)
+			}
+			return nil
+		})
+}
+
+func TestOrderDomainEvents(t *testing.T) {
+	t.Run("创建订单提交后分发事件", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		mockDispatcher := mocks.NewMockEventDispatcher(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithEventDispatcher(mockDispatcher))
+
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		expectTransactionWithAfterCommit(mockTxManager, func() {
+			mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), gomock.Any()).Return(int8(1), nil)
+			mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, order *models.Order) (uint64, error) {
+					order.OrderID = 1001
+					return order.OrderID, nil
+				})
+		})
+		mockDispatcher.EXPECT().Dispatch(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, events ...models.DomainEvent) {
+				if assert.Len(t, events, 2) {
+					assert.Equal(t, uint64(1001), events[0].(models.OrderCreated).OrderID)
+					assert.Equal(t, models.ConsumptionChanged{UserID: 3, Delta: models.MustParseMoney("500")}, events[1])
+				}
+			}).Return(nil)
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500")})
+		assert.NoError(t, err)
+	})
+
+	t.Run("事务回滚时不分发", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		mockDispatcher := mocks.NewMockEventDispatcher(ctrl) // 没有 EXPECT：调用 Dispatch 即失败
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithEventDispatcher(mockDispatcher))
+
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(3)).Return(&models.User{ID: 3}, nil)
+		expectTransactionWithAfterCommit(mockTxManager, func() {
+			mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(3), gomock.Any()).Return(int8(1), nil)
+			mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), errors.New("db error"))
+		})
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderCommand{UserID: 3, Amount: models.MustParseMoney("500")})
+		assert.Error(t, err)
+	})
+
+	t.Run("失效订单提交后分发事件，订阅者失败不影响结果", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTxManager := mocks.NewMockTransactionManager(ctrl)
+		mockDispatcher := mocks.NewMockEventDispatcher(ctrl)
+		service := services.NewOrderService(mockUserRepo, mockOrderRepo, mockTxManager, services.WithEventDispatcher(mockDispatcher))
+
+		mockOrderRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.Order{
+			OrderID: 1001, UserID: 2001, Amount: models.MustParseMoney("500"), BaseAmount: models.MustParseMoney("500"), Status: models.OrderPaid,
+		}, nil)
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(2001)).
+			Return(&models.User{ID: 2001, TotalConsumption: models.MustParseMoney("1500")}, nil)
+		expectTransactionWithAfterCommit(mockTxManager, func() {
+			mockOrderRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), models.OrderPaid).Return(int8(1), nil)
+			mockUserRepo.EXPECT().AddTotalConsumption(gomock.Any(), uint64(2001), models.MustParseMoney("-500")).Return(int8(1), nil)
+		})
+		mockDispatcher.EXPECT().Dispatch(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, events ...models.DomainEvent) {
+				if assert.Len(t, events, 2) {
+					assert.Equal(t, models.EventOrderInvalidated, events[0].EventName())
+					assert.Equal(t, models.ConsumptionChanged{UserID: 2001, Delta: models.MustParseMoney("-500")}, events[1])
+				}
+			}).Return(errors.New("handler failed"))
+
+		assert.NoError(t, service.InvalidateOrder(context.Background(), services.InvalidateOrderCommand{OrderID: 1001}))
 	})
 }
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// OutboxRelay 发件箱中继：把已提交的发件箱消息发布出去
+//   - 至少一次：先发布再标记，标记失败时消息会被再次发布，消费方需要幂等
+//   - 同一聚合内有序：某条消息发布失败时，同一聚合的后续消息等它重试成功后再发布
+//   - 重试：失败后按 RetryBackoff 起始、每次加倍、不超过 MaxBackoff 的间隔重试；
+//     失败 MaxAttempts 次后停放，不再发布也不再阻塞同一聚合的后续消息，需要人工处理
+//   - 清理：已发布超过 Retention 的消息会被删除
+//
+// 同一时刻只应运行一个中继实例
+type OutboxRelay struct {
+	outboxRepo repositories.OutboxRepository
+	publisher  repositories.Publisher
+
+	BatchSize int           // 每批读取的消息数
+	Interval  time.Duration // Run 的轮询间隔
+	Retention time.Duration // 已发布消息的保留时长
+
+	MaxAttempts  int           // 发布失败达到该次数后停放消息，为0表示不限制
+	RetryBackoff time.Duration // 第一次失败后的重试间隔
+	MaxBackoff   time.Duration // 重试间隔的上限
+
+	Logger repositories.Logger // 记录停放与 Run 中的错误，默认只输出文案键与参数
+}
+
+func NewOutboxRelay(repo repositories.OutboxRepository, publisher repositories.Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: repo,
+		publisher:  publisher,
+		BatchSize:  100,
+		Interval:   time.Second,
+		Retention:  24 * time.Hour,
+
+		MaxAttempts:  10,
+		RetryBackoff: time.Second,
+		MaxBackoff:   10 * time.Minute,
+
+		Logger: keyLogger{},
+	}
+}
+
+// RelayOnce 发布一批待发布消息，返回成功发布的条数
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	msgs, err := r.outboxRepo.FindPending(ctx, now, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := make(map[string]bool) // 本批次中发布失败的聚合
+	for _, msg := range msgs {
+		key := msg.AggregateKey()
+		if blocked[key] {
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, msg); err != nil {
+			blocked[key] = true
+			if markErr := r.markFailed(ctx, msg, err, now); markErr != nil {
+				return published, markErr
+			}
+			continue
+		}
+
+		if err := r.outboxRepo.MarkPublished(ctx, msg.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// markFailed 记录发布失败：未达到 MaxAttempts 时安排重试，否则停放
+func (r *OutboxRelay) markFailed(ctx context.Context, msg *models.OutboxMessage, err error, now time.Time) error {
+	attempts := msg.Attempts + 1
+	if r.MaxAttempts > 0 && attempts >= r.MaxAttempts {
+		r.Logger.Log(ctx, "log.outbox_parked", map[string]any{"message_id": msg.ID, "attempts": attempts, "error": err})
+		return r.outboxRepo.MarkDead(ctx, msg.ID, err.Error(), now)
+	}
+	return r.outboxRepo.MarkFailed(ctx, msg.ID, err.Error(), now.Add(r.backoff(attempts)))
+}
+
+// backoff 第 attempts 次失败后的重试间隔
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.RetryBackoff
+	for i := 1; i < attempts && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	return delay
+}
+
+// Cleanup 删除已发布超过 Retention 的消息
+func (r *OutboxRelay) Cleanup(ctx context.Context) (int64, error) {
+	return r.outboxRepo.DeletePublishedBefore(ctx, time.Now().Add(-r.Retention))
+}
+
+// Run 按 Interval 循环发布与清理，直到 ctx 结束
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil {
+			r.Logger.Log(ctx, "log.outbox_relay_failed", map[string]any{"error": err})
+		}
+		if _, err := r.Cleanup(ctx); err != nil {
+			r.Logger.Log(ctx, "log.outbox_cleanup_failed", map[string]any{"error": err})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
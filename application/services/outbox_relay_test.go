@@ -0,0 +1,156 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/interfaces/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger 记录日志的文案键与参数
+type recordingLogger struct {
+	keys   []string
+	params []map[string]any
+}
+
+func (l *recordingLogger) Log(_ context.Context, key string, params map[string]any) {
+	l.keys = append(l.keys, key)
+	l.params = append(l.params, params)
+}
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+	mockPublisher := mocks.NewMockPublisher(ctrl)
+
+	relay := services.NewOutboxRelay(mockOutboxRepo, mockPublisher)
+
+	t.Run("按顺序发布并标记", func(t *testing.T) {
+		msgs := []*models.OutboxMessage{
+			{ID: 1, AggregateType: models.AggregateOrder, AggregateID: 1001},
+			{ID: 2, AggregateType: models.AggregateUser, AggregateID: 1},
+		}
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(msgs, nil)
+
+		gomock.InOrder(
+			mockPublisher.EXPECT().Publish(gomock.Any(), msgs[0]).Return(nil),
+			mockOutboxRepo.EXPECT().MarkPublished(gomock.Any(), uint64(1), gomock.Any()).Return(nil),
+			mockPublisher.EXPECT().Publish(gomock.Any(), msgs[1]).Return(nil),
+			mockOutboxRepo.EXPECT().MarkPublished(gomock.Any(), uint64(2), gomock.Any()).Return(nil),
+		)
+
+		published, err := relay.RelayOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, published)
+	})
+
+	t.Run("发布失败时同一聚合的后续消息不再发布", func(t *testing.T) {
+		msgs := []*models.OutboxMessage{
+			{ID: 3, AggregateType: models.AggregateOrder, AggregateID: 1001},
+			{ID: 4, AggregateType: models.AggregateUser, AggregateID: 1},
+			{ID: 5, AggregateType: models.AggregateOrder, AggregateID: 1001},
+		}
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(msgs, nil)
+
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[0]).Return(errors.New("broker down"))
+		mockOutboxRepo.EXPECT().MarkFailed(gomock.Any(), uint64(3), "broker down", gomock.Any()).
+			Do(func(_ context.Context, _ uint64, _ string, next time.Time) {
+				// 第一次失败按 RetryBackoff 重试
+				assert.WithinDuration(t, time.Now().Add(time.Second), next, 500*time.Millisecond)
+			}).
+			Return(nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[1]).Return(nil)
+		mockOutboxRepo.EXPECT().MarkPublished(gomock.Any(), uint64(4), gomock.Any()).Return(nil)
+		// msgs[2] 与 msgs[0] 属于同一订单，不会被发布
+
+		published, err := relay.RelayOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+	})
+
+	t.Run("重试间隔加倍且不超过上限", func(t *testing.T) {
+		msgs := []*models.OutboxMessage{{ID: 6, AggregateType: models.AggregateOrder, AggregateID: 1002, Attempts: 3}}
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(msgs, nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[0]).Return(errors.New("broker down"))
+		mockOutboxRepo.EXPECT().MarkFailed(gomock.Any(), uint64(6), "broker down", gomock.Any()).
+			Do(func(_ context.Context, _ uint64, _ string, next time.Time) {
+				// 第4次失败：1s * 2^3
+				assert.WithinDuration(t, time.Now().Add(8*time.Second), next, 500*time.Millisecond)
+			}).
+			Return(nil)
+		_, err := relay.RelayOnce(context.Background())
+		assert.NoError(t, err)
+
+		msgs[0].Attempts = 8
+		relay.MaxBackoff = 30 * time.Second
+		defer func() { relay.MaxBackoff = 10 * time.Minute }()
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(msgs, nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[0]).Return(errors.New("broker down"))
+		mockOutboxRepo.EXPECT().MarkFailed(gomock.Any(), uint64(6), "broker down", gomock.Any()).
+			Do(func(_ context.Context, _ uint64, _ string, next time.Time) {
+				assert.WithinDuration(t, time.Now().Add(30*time.Second), next, 500*time.Millisecond)
+			}).
+			Return(nil)
+		_, err = relay.RelayOnce(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("失败次数达到上限时停放，不影响其他聚合", func(t *testing.T) {
+		msgs := []*models.OutboxMessage{
+			{ID: 7, AggregateType: models.AggregateOrder, AggregateID: 1003, Attempts: 9},
+			{ID: 8, AggregateType: models.AggregateUser, AggregateID: 2},
+		}
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(msgs, nil)
+
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[0]).Return(errors.New("invalid payload"))
+		mockOutboxRepo.EXPECT().MarkDead(gomock.Any(), uint64(7), "invalid payload", gomock.Any()).Return(nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), msgs[1]).Return(nil)
+		mockOutboxRepo.EXPECT().MarkPublished(gomock.Any(), uint64(8), gomock.Any()).Return(nil)
+
+		logger := &recordingLogger{}
+		relay.Logger = logger
+		published, err := relay.RelayOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+		if assert.Equal(t, []string{"log.outbox_parked"}, logger.keys) {
+			assert.Equal(t, uint64(7), logger.params[0]["message_id"])
+			assert.Equal(t, 10, logger.params[0]["attempts"])
+		}
+	})
+
+	t.Run("读取失败", func(t *testing.T) {
+		mockOutboxRepo.EXPECT().FindPending(gomock.Any(), gomock.Any(), 100).Return(nil, errors.New("db error"))
+
+		_, err := relay.RelayOnce(context.Background())
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestOutboxRelay_Cleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutboxRepo := mocks.NewMockOutboxRepository(ctrl)
+	relay := services.NewOutboxRelay(mockOutboxRepo, mocks.NewMockPublisher(ctrl))
+	relay.Retention = time.Hour
+
+	t.Run("删除保留期之前已发布的消息", func(t *testing.T) {
+		mockOutboxRepo.EXPECT().DeletePublishedBefore(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, before time.Time) {
+				assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Minute)
+			}).
+			Return(int64(3), nil)
+
+		deleted, err := relay.Cleanup(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+}
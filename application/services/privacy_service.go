@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// PrivacyAppService 用户数据导出与个人信息抹除
+type PrivacyAppService struct {
+	userRepo        repositories.UserRepository
+	orderRepo       repositories.OrderRepository
+	refundRepo      repositories.RefundRepository      // 可选，为空时导出不包含退款记录
+	emailChangeRepo repositories.EmailChangeRepository // 可选，为空时抹除不处理邮箱变更请求
+	auditRepo       repositories.AuditRepository
+	txManager       repositories.TransactionManager
+}
+
+func NewPrivacyAppService(ur repositories.UserRepository, or repositories.OrderRepository,
+	rr repositories.RefundRepository, ecr repositories.EmailChangeRepository,
+	ar repositories.AuditRepository, tm repositories.TransactionManager) *PrivacyAppService {
+	return &PrivacyAppService{
+		userRepo:        ur,
+		orderRepo:       or,
+		refundRepo:      rr,
+		emailChangeRepo: ecr,
+		auditRepo:       ar,
+		txManager:       tm,
+	}
+}
+
+// ExportUserData: 导出用户的资料、订单（含明细与退款）及审计记录，返回 JSON 归档
+// 已注销的用户同样可以导出
+func (s *PrivacyAppService) ExportUserData(ctx context.Context, userID uint64) ([]byte, error) {
+	ctx = repositories.IncludeDeleted(ctx)
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, models.ErrUserNotFound.With("user_id", userID))
+	}
+
+	orders, err := s.orderRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	refunds := make(map[uint64][]*models.Refund)
+	if s.refundRepo != nil {
+		for _, order := range orders {
+			if refunds[order.OrderID], err = s.refundRepo.FindByOrderID(ctx, order.OrderID); err != nil {
+				return nil, dbError(err)
+			}
+		}
+	}
+	audits, err := s.auditRepo.FindBySubject(ctx, models.AggregateUser, userID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	export := models.NewUserExport(user, orders, refunds, audits, time.Now())
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// EraseUserCommand 抹除用户个人信息的命令
+type EraseUserCommand struct {
+	UserID uint64
+	Actor  string // 操作人，写入审计记录
+}
+
+// EraseUser: 抹除用户的个人信息并注销用户，订单等财务数据保留以便对账
+// 用户更新、邮箱变更请求的删除与审计记录在同一事务中写入
+func (s *PrivacyAppService) EraseUser(ctx context.Context, cmd EraseUserCommand) error {
+	actor := strings.TrimSpace(cmd.Actor)
+	if actor == "" {
+		return models.ErrInvalidArgument.With("field", "actor")
+	}
+	ctx = repositories.IncludeDeleted(ctx)
+
+	return s.txManager.Transaction(ctx, func(txCtx context.Context) error {
+		// 锁定用户，避免与并发的资料修改交错
+		user, err := s.userRepo.FindByIDForUpdate(txCtx, cmd.UserID, repositories.LockWait)
+		if err != nil {
+			return notFound(err, models.ErrUserNotFound.With("user_id", cmd.UserID))
+		}
+		if err := user.Anonymize(time.Now()); err != nil {
+			return err
+		}
+		_, err = s.userRepo.UpdateFields(txCtx, user, "Name", "Email", "CanonicalEmail", "Status", "DeletedAt", "ErasedAt")
+		if err != nil {
+			return dbError(err)
+		}
+
+		var detail models.UserErasedDetail
+		orders, err := s.orderRepo.FindByUserID(txCtx, user.ID)
+		if err != nil {
+			return dbError(err)
+		}
+		detail.Orders = len(orders)
+		if s.emailChangeRepo != nil {
+			removed, err := s.emailChangeRepo.DeleteByUserID(txCtx, user.ID)
+			if err != nil {
+				return dbError(err)
+			}
+			detail.EmailChangesRemoved = int(removed)
+		}
+
+		entry, err := models.NewAuditEntry(actor, models.AuditUserErased, models.AggregateUser, user.ID, detail)
+		if err != nil {
+			return err
+		}
+		if _, err := s.auditRepo.Save(txCtx, entry); err != nil {
+			return dbError(err)
+		}
+		return nil
+	})
+}
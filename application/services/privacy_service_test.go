@@ -0,0 +1,144 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/interfaces/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUserData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	service := services.NewPrivacyAppService(mockUserRepo, mockOrderRepo, mockRefundRepo, nil, mockAuditRepo, mockTxManager)
+
+	t.Run("导出资料、订单、退款与审计记录", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).
+			DoAndReturn(func(ctx context.Context, _ uint64) (*models.User, error) {
+				assert.True(t, repositories.IncludesDeleted(ctx), "已注销的用户同样可以导出")
+				return &models.User{ID: 1001, Name: "Alice", Email: "alice@corp.com"}, nil
+			})
+		mockOrderRepo.EXPECT().FindByUserID(gomock.Any(), uint64(1001)).Return([]*models.Order{
+			{OrderID: 1, UserID: 1001, Amount: models.MustParseMoney("100"), ExchangeRate: models.RateOne},
+			{OrderID: 2, UserID: 1001, Amount: models.MustParseMoney("50"), ExchangeRate: models.RateOne},
+		}, nil)
+		mockRefundRepo.EXPECT().FindByOrderID(gomock.Any(), uint64(1)).
+			Return([]*models.Refund{{ID: 1, OrderID: 1, Amount: models.MustParseMoney("10")}}, nil)
+		mockRefundRepo.EXPECT().FindByOrderID(gomock.Any(), uint64(2)).Return([]*models.Refund{}, nil)
+		mockAuditRepo.EXPECT().FindBySubject(gomock.Any(), models.AggregateUser, uint64(1001)).Return([]*models.AuditEntry{}, nil)
+
+		data, err := service.ExportUserData(context.Background(), 1001)
+		assert.NoError(t, err)
+
+		var archive models.UserExport
+		assert.NoError(t, json.Unmarshal(data, &archive))
+		assert.Equal(t, "alice@corp.com", archive.Profile.Email)
+		if assert.Len(t, archive.Orders, 2) {
+			assert.Len(t, archive.Orders[0].Refunds, 1)
+			assert.Empty(t, archive.Orders[1].Refunds)
+		}
+	})
+
+	t.Run("用户不存在", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(404)).Return(nil, repositories.ErrorNotFound)
+
+		_, err := service.ExportUserData(context.Background(), 404)
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+}
+
+func TestEraseUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockEmailChangeRepo := mocks.NewMockEmailChangeRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	service := services.NewPrivacyAppService(mockUserRepo, mockOrderRepo, nil, mockEmailChangeRepo, mockAuditRepo, mockTxManager)
+
+	t.Run("在同一事务中抹除个人信息并写入审计记录", func(t *testing.T) {
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint64(1001), repositories.LockWait).
+					Return(&models.User{ID: 1001, Name: "Alice", Email: "alice@corp.com", CanonicalEmail: "alice@corp.com",
+						TotalConsumption: models.MustParseMoney("150"), Status: models.UserActive}, nil)
+				mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(),
+					"Name", "Email", "CanonicalEmail", "Status", "DeletedAt", "ErasedAt").
+					Do(func(_ context.Context, user *models.User, _ ...string) {
+						assert.NotEqual(t, "Alice", user.Name)
+						assert.NotContains(t, user.Email, "alice")
+						assert.Equal(t, models.UserDeleted, user.Status)
+						assert.NotNil(t, user.ErasedAt)
+						assert.Equal(t, models.MustParseMoney("150"), user.TotalConsumption)
+					}).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().FindByUserID(gomock.Any(), uint64(1001)).
+					Return([]*models.Order{{OrderID: 1}, {OrderID: 2}}, nil)
+				mockEmailChangeRepo.EXPECT().DeleteByUserID(gomock.Any(), uint64(1001)).Return(int64(1), nil)
+				mockAuditRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, entry *models.AuditEntry) {
+						assert.Equal(t, "admin", entry.Actor)
+						assert.Equal(t, models.AuditUserErased, entry.Action)
+						assert.Equal(t, uint64(1001), entry.SubjectID)
+						assert.JSONEq(t, `{"orders":2,"email_changes_removed":1}`, entry.Detail)
+						assert.False(t, strings.Contains(entry.Detail, "alice"), "审计记录不得包含个人信息")
+					}).Return(uint64(1), nil)
+				return fn(ctx)
+			})
+
+		err := service.EraseUser(context.Background(), services.EraseUserCommand{UserID: 1001, Actor: "admin"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("写入审计记录失败时整体回滚", func(t *testing.T) {
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint64(1001), repositories.LockWait).
+					Return(&models.User{ID: 1001, Name: "Alice", Email: "alice@corp.com"}, nil)
+				mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), gomock.Any()).Return(int8(1), nil)
+				mockOrderRepo.EXPECT().FindByUserID(gomock.Any(), uint64(1001)).Return([]*models.Order{}, nil)
+				mockEmailChangeRepo.EXPECT().DeleteByUserID(gomock.Any(), uint64(1001)).Return(int64(0), nil)
+				mockAuditRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), errors.New("disk full"))
+				return fn(ctx)
+			})
+
+		err := service.EraseUser(context.Background(), services.EraseUserCommand{UserID: 1001, Actor: "admin"})
+		assert.ErrorContains(t, err, "disk full")
+	})
+
+	t.Run("已抹除的用户", func(t *testing.T) {
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				erased := &models.User{ID: 1001}
+				assert.NoError(t, erased.Anonymize(time.Now()))
+				mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint64(1001), repositories.LockWait).Return(erased, nil)
+				return fn(ctx)
+			})
+
+		err := service.EraseUser(context.Background(), services.EraseUserCommand{UserID: 1001, Actor: "admin"})
+		assert.ErrorIs(t, err, models.ErrUserErased)
+	})
+
+	t.Run("操作人不能为空", func(t *testing.T) {
+		err := service.EraseUser(context.Background(), services.EraseUserCommand{UserID: 1001})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// ProductAppService 商品目录应用服务
+type ProductAppService struct {
+	productRepo repositories.ProductRepository
+}
+
+func NewProductAppService(pr repositories.ProductRepository) *ProductAppService {
+	return &ProductAppService{productRepo: pr}
+}
+
+// CreateProductCommand 新增商品的命令
+type CreateProductCommand struct {
+	SKU       string // SKU为unique
+	Name      string
+	UnitPrice models.Money
+	Currency  models.Currency // 可选，默认为基准币种
+	Stock     int             // 可选，初始库存
+}
+
+// CreateProduct: 新增商品，返回商品ID
+func (s *ProductAppService) CreateProduct(ctx context.Context, cmd CreateProductCommand) (uint64, error) {
+	product, err := models.NewProduct(cmd.SKU, cmd.Name, cmd.UnitPrice, cmd.Currency)
+	if err != nil {
+		return 0, err
+	}
+	if cmd.Stock < 0 {
+		return 0, models.ErrInvalidQuantity.With("field", "stock").With("quantity", cmd.Stock)
+	}
+	product.Stock = cmd.Stock
+
+	productID, err := s.productRepo.Save(ctx, product)
+	if errors.Is(err, repositories.ErrorDuplicate) {
+		return 0, models.ErrSKUTaken.With("sku", product.SKU).Wrap(err)
+	} else if err != nil {
+		return 0, dbError(err)
+	}
+	return productID, nil
+}
+
+// RestockCommand 补货的命令
+type RestockCommand struct {
+	SKU      string
+	Quantity int
+}
+
+// Restock: 按SKU补货，库存增量在数据库中原子执行，不会覆盖并发下单的扣减
+func (s *ProductAppService) Restock(ctx context.Context, cmd RestockCommand) error {
+	product, err := s.productRepo.FindBySKU(ctx, strings.TrimSpace(cmd.SKU))
+	if err != nil {
+		return notFound(err, models.ErrProductNotFound.With("sku", cmd.SKU))
+	}
+	if err := product.Restock(cmd.Quantity); err != nil {
+		return err
+	}
+
+	if err := s.productRepo.IncrementStock(ctx, product.ID, cmd.Quantity); err != nil {
+		return dbError(err)
+	}
+	return nil
+}
+
+// LowStockReport: 库存不高于 threshold 的商品，按库存升序
+func (s *ProductAppService) LowStockReport(ctx context.Context, threshold int) ([]*models.Product, error) {
+	if threshold < 0 {
+		return nil, models.ErrInvalidArgument.With("field", "threshold")
+	}
+	products, err := s.productRepo.FindLowStock(ctx, threshold)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return products, nil
+}
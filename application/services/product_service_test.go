@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/interfaces/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateProduct(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductRepo := mocks.NewMockProductRepository(ctrl)
+
+	service := services.NewProductAppService(mockProductRepo)
+
+	t.Run("成功创建商品", func(t *testing.T) {
+		mockProductRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, product *models.Product) {
+				assert.Equal(t, "SKU-001", product.SKU)
+				assert.Equal(t, models.MustParseMoney("19.9"), product.UnitPrice)
+				assert.Equal(t, models.BaseCurrency, product.Currency)
+				assert.Equal(t, 20, product.Stock)
+			}).Return(uint64(1), nil)
+
+		productID, err := service.CreateProduct(context.Background(), services.CreateProductCommand{
+			SKU: " SKU-001 ", Name: "水杯", UnitPrice: models.MustParseMoney("19.9"), Stock: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), productID)
+	})
+
+	t.Run("SKU重复", func(t *testing.T) {
+		mockProductRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), repositories.ErrorDuplicate)
+
+		_, err := service.CreateProduct(context.Background(), services.CreateProductCommand{
+			SKU: "SKU-001", Name: "水杯", UnitPrice: models.MustParseMoney("19.9")})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	t.Run("单价为负数", func(t *testing.T) {
+		_, err := service.CreateProduct(context.Background(), services.CreateProductCommand{
+			SKU: "SKU-002", Name: "水杯", UnitPrice: models.MustParseMoney("-1")})
+		assert.ErrorIs(t, err, models.ErrInvalidProduct)
+	})
+}
+
+func TestRestock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductRepo := mocks.NewMockProductRepository(ctrl)
+
+	service := services.NewProductAppService(mockProductRepo)
+
+	t.Run("成功补货", func(t *testing.T) {
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-001").Return(&models.Product{ID: 1, SKU: "SKU-001", Stock: 2}, nil)
+		mockProductRepo.EXPECT().IncrementStock(gomock.Any(), uint64(1), 10).Return(nil)
+
+		err := service.Restock(context.Background(), services.RestockCommand{SKU: "SKU-001", Quantity: 10})
+		assert.NoError(t, err)
+	})
+
+	t.Run("补货数量必须大于0", func(t *testing.T) {
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-001").Return(&models.Product{ID: 1, SKU: "SKU-001"}, nil)
+
+		err := service.Restock(context.Background(), services.RestockCommand{SKU: "SKU-001", Quantity: 0})
+		assert.ErrorIs(t, err, models.ErrInvalidQuantity)
+	})
+
+	t.Run("商品不存在", func(t *testing.T) {
+		mockProductRepo.EXPECT().FindBySKU(gomock.Any(), "SKU-404").Return(nil, repositories.ErrorNotFound)
+
+		err := service.Restock(context.Background(), services.RestockCommand{SKU: "SKU-404", Quantity: 1})
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+}
+
+func TestLowStockReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductRepo := mocks.NewMockProductRepository(ctrl)
+
+	service := services.NewProductAppService(mockProductRepo)
+
+	t.Run("返回库存不高于阈值的商品", func(t *testing.T) {
+		low := []*models.Product{{ID: 2, SKU: "SKU-002", Stock: 0}, {ID: 1, SKU: "SKU-001", Stock: 3}}
+		mockProductRepo.EXPECT().FindLowStock(gomock.Any(), 5).Return(low, nil)
+
+		products, err := service.LowStockReport(context.Background(), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, low, products)
+	})
+
+	t.Run("阈值为负数", func(t *testing.T) {
+		_, err := service.LowStockReport(context.Background(), -1)
+		assert.Error(t, err)
+	})
+}
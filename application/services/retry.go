@@ -0,0 +1,21 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// maxConflictRetries 乐观锁冲突时最多重新执行的次数
+const maxConflictRetries = 3
+
+// retryOnConflict 执行 fn，遇到乐观锁冲突时重新执行（fn 需要自行重新读取数据）
+func retryOnConflict(fn func() error) error {
+	var err error
+	for i := 0; i <= maxConflictRetries; i++ {
+		if err = fn(); !errors.Is(err, repositories.ErrorVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// TierAppService 客户等级应用服务：等级门槛配置与按等级查询
+// 等级本身在订单事务中随消费总额重新计算（见 WithTiers）
+type TierAppService struct {
+	tierRepo repositories.TierRepository
+	userRepo repositories.UserRepository
+}
+
+func NewTierAppService(tr repositories.TierRepository, ur repositories.UserRepository) *TierAppService {
+	return &TierAppService{tierRepo: tr, userRepo: ur}
+}
+
+// SetTierThresholdCommand 新增或修改等级门槛的命令
+type SetTierThresholdCommand struct {
+	Tier           string // 如 bronze、silver、gold，不区分大小写
+	MinConsumption models.Money
+}
+
+// SetTierThreshold: 新增或修改等级门槛
+// 修改只影响之后消费总额发生变化的用户，已有用户的等级不会立即重新计算
+func (s *TierAppService) SetTierThreshold(ctx context.Context, cmd SetTierThresholdCommand) error {
+	threshold, err := models.NewTierThreshold(cmd.Tier, cmd.MinConsumption)
+	if err != nil {
+		return err
+	}
+	err = s.tierRepo.SaveThreshold(ctx, threshold)
+	if errors.Is(err, repositories.ErrorDuplicate) {
+		return models.ErrTierThresholdTaken.With("min_consumption", threshold.MinConsumption).Wrap(err)
+	} else if err != nil {
+		return dbError(err)
+	}
+	return nil
+}
+
+// TierThresholds: 全部等级门槛，按门槛升序
+func (s *TierAppService) TierThresholds(ctx context.Context) (models.TierSchedule, error) {
+	schedule, err := s.tierRepo.FindThresholds(ctx)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return schedule, nil
+}
+
+// UsersByTier: 按用户ID升序分页查询指定等级的用户，tier 为空时查询无等级的用户
+func (s *TierAppService) UsersByTier(ctx context.Context, tier string, offset int, limit int) ([]*models.User, error) {
+	if offset < 0 || limit <= 0 {
+		return nil, models.ErrInvalidArgument.With("field", "pagination")
+	}
+	users, err := s.userRepo.FindByTier(ctx, strings.ToLower(strings.TrimSpace(tier)), offset, limit)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return users, nil
+}
+
+// TierHistory: 用户的等级变化记录，按时间先后返回
+func (s *TierAppService) TierHistory(ctx context.Context, userID uint64) ([]*models.TierChange, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, notFound(err, models.ErrUserNotFound.With("user_id", userID))
+	}
+	changes, err := s.tierRepo.FindChangesByUserID(ctx, userID)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/interfaces/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTierThreshold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockTierRepo := mocks.NewMockTierRepository(ctrl)
+	service := services.NewTierAppService(mockTierRepo, mocks.NewMockUserRepository(ctrl))
+
+	t.Run("等级名称规范化后保存", func(t *testing.T) {
+		mockTierRepo.EXPECT().SaveThreshold(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, threshold *models.TierThreshold) {
+				assert.Equal(t, "gold", threshold.Tier)
+				assert.Equal(t, models.MustParseMoney("10000"), threshold.MinConsumption)
+			}).Return(nil)
+		err := service.SetTierThreshold(context.Background(), services.SetTierThresholdCommand{
+			Tier: " Gold ", MinConsumption: models.MustParseMoney("10000"),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("门槛与其他等级重复", func(t *testing.T) {
+		mockTierRepo.EXPECT().SaveThreshold(gomock.Any(), gomock.Any()).Return(repositories.ErrorDuplicate)
+		err := service.SetTierThreshold(context.Background(), services.SetTierThresholdCommand{
+			Tier: "silver", MinConsumption: models.MustParseMoney("10000"),
+		})
+		assert.True(t, errors.Is(err, repositories.ErrorDuplicate))
+	})
+
+	t.Run("负数门槛", func(t *testing.T) {
+		err := service.SetTierThreshold(context.Background(), services.SetTierThresholdCommand{
+			Tier: "bronze", MinConsumption: models.MustParseMoney("-1"),
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestUsersByTier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	service := services.NewTierAppService(mocks.NewMockTierRepository(ctrl), mockUserRepo)
+
+	mockUserRepo.EXPECT().FindByTier(gomock.Any(), "gold", 20, 10).
+		Return([]*models.User{{ID: 1, Tier: "gold"}}, nil)
+	users, err := service.UsersByTier(context.Background(), " GOLD", 20, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	_, err = service.UsersByTier(context.Background(), "gold", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestTierHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockTierRepo := mocks.NewMockTierRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	service := services.NewTierAppService(mockTierRepo, mockUserRepo)
+
+	t.Run("返回等级变化记录", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(7)).Return(&models.User{ID: 7}, nil)
+		mockTierRepo.EXPECT().FindChangesByUserID(gomock.Any(), uint64(7)).Return([]*models.TierChange{
+			{UserID: 7, FromTier: "", ToTier: "bronze"},
+			{UserID: 7, FromTier: "bronze", ToTier: "silver"},
+		}, nil)
+		changes, err := service.TierHistory(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 2)
+	})
+
+	t.Run("用户不存在", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(8)).Return(nil, repositories.ErrorNotFound)
+		_, err := service.TierHistory(context.Background(), 8)
+		assert.True(t, errors.Is(err, repositories.ErrorNotFound))
+	})
+}
@@ -1,8 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
@@ -10,15 +11,67 @@ import (
 
 // UserAppService 用户应用服务（事务编排中心）
 type UserAppService struct {
-	userRepo repositories.UserRepository
+	userRepo        repositories.UserRepository
+	emailPolicy     models.EmailPolicy                 // 注册时的邮箱校验策略
+	emailChangeRepo repositories.EmailChangeRepository // 可选，为空时不支持修改邮箱
+	txManager       repositories.TransactionManager
+	emailChangeTTL  time.Duration                // 邮箱变更令牌的有效期
+	dispatcher      repositories.EventDispatcher // 可选，为空时不分发领域事件
+	logger          repositories.Logger          // 记录订阅者错误等运行日志
 }
 
-func NewUserAppService(ur repositories.UserRepository) *UserAppService {
-	return &UserAppService{
-		userRepo: ur,
+// defaultEmailChangeTTL 邮箱变更令牌的默认有效期
+const defaultEmailChangeTTL = 24 * time.Hour
+
+// UserServiceOption 用户应用服务的可选依赖
+type UserServiceOption func(*UserAppService)
+
+// WithEmailPolicy 使用指定的邮箱校验策略，默认为 models.DefaultEmailPolicy
+func WithEmailPolicy(policy models.EmailPolicy) UserServiceOption {
+	return func(u *UserAppService) {
+		u.emailPolicy = policy
+	}
+}
+
+// WithEmailChange 启用 ChangeEmail / ConfirmEmailChange，ttl 为令牌有效期（不大于0时使用默认的24小时）
+func WithEmailChange(repo repositories.EmailChangeRepository, tm repositories.TransactionManager, ttl time.Duration) UserServiceOption {
+	return func(u *UserAppService) {
+		u.emailChangeRepo = repo
+		u.txManager = tm
+		if ttl > 0 {
+			u.emailChangeTTL = ttl
+		}
+	}
+}
+
+// WithUserEventDispatcher 在用户保存（所在事务提交）后把用户记录的领域事件交给 dispatcher
+func WithUserEventDispatcher(dispatcher repositories.EventDispatcher, tm repositories.TransactionManager) UserServiceOption {
+	return func(u *UserAppService) {
+		u.dispatcher = dispatcher
+		u.txManager = tm
 	}
 }
 
+// WithUserLogger 使用 logger 输出运行日志（如本地化的文案），默认只输出文案键与参数
+func WithUserLogger(logger repositories.Logger) UserServiceOption {
+	return func(u *UserAppService) {
+		u.logger = logger
+	}
+}
+
+func NewUserAppService(ur repositories.UserRepository, opts ...UserServiceOption) *UserAppService {
+	u := &UserAppService{
+		userRepo:       ur,
+		logger:         keyLogger{},
+		emailPolicy:    models.DefaultEmailPolicy(),
+		emailChangeTTL: defaultEmailChangeTTL,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
 // 新增用户的命令
 type CreateNewUserCommand struct {
 	Name  string
@@ -26,26 +79,211 @@ type CreateNewUserCommand struct {
 }
 
 // CreateNewUser: 新增用户
-func (u *UserAppService) CreateNewUser(cmd CreateNewUserCommand) (uint64, error) {
+// 邮箱按规范形式判断是否已存在，例如 A@qq.com 与 a@qq.com 视为同一个邮箱
+func (u *UserAppService) CreateNewUser(ctx context.Context, cmd CreateNewUserCommand) (uint64, error) {
 	// 1. 检查邮箱是否存在
-	exist_user, err := u.userRepo.FindByEmail(cmd.Email)
+	exist_user, err := u.userRepo.FindByEmail(ctx, u.emailPolicy.Canonicalize(cmd.Email))
 	if err != nil && !errors.Is(err, repositories.ErrorNotFound) {
-		return 0, fmt.Errorf("DB error: %w", err)
+		return 0, dbError(err)
 	}
 	if exist_user != nil {
-		return exist_user.ID, errors.New(fmt.Sprintf("邮箱%s已存在", cmd.Email))
+		return exist_user.ID, models.ErrEmailTaken.With("email", cmd.Email)
 	}
 
 	// 2. 创建用户
-	new_user, err := models.CreateUser(cmd.Name, cmd.Email)
+	new_user, err := models.CreateUser(cmd.Name, cmd.Email, u.emailPolicy)
 	if err != nil {
-		return 0, fmt.Errorf("创建用户失败: %w", err)
+		return 0, err
 	}
 
-	// 3. 存储用户
-	userid, err := u.userRepo.Save(new_user)
-	if err != nil {
-		return 0, fmt.Errorf("DB error: %w", err)
+	// 3. 存储用户（并发注册同一邮箱时由唯一索引兜底）
+	userid, err := u.userRepo.Save(ctx, new_user)
+	if errors.Is(err, repositories.ErrorDuplicate) {
+		return 0, models.ErrEmailTaken.With("email", cmd.Email).Wrap(err)
+	} else if err != nil {
+		return 0, dbError(err)
 	}
+	// ctx 在调用方的事务中时，等该事务提交后再分发
+	publishAfterCommit(ctx, u.txManager, u.dispatcher, u.logger, new_user)
 	return userid, nil
 }
+
+// UpdateUserProfileCommand 修改用户资料的命令
+type UpdateUserProfileCommand struct {
+	UserID uint64
+	Name   string
+}
+
+// UpdateUserProfile: 修改用户名，只写入发生变化的字段；用户名未变化时不访问数据库写入
+func (u *UserAppService) UpdateUserProfile(ctx context.Context, cmd UpdateUserProfileCommand) error {
+	return retryOnConflict(func() error {
+		user, err := u.userRepo.FindByID(ctx, cmd.UserID)
+		if err != nil {
+			return notFound(err, models.ErrUserNotFound.With("user_id", cmd.UserID))
+		}
+
+		changed, err := user.Rename(cmd.Name)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		if _, err := u.userRepo.UpdateFields(ctx, user, "Name"); err != nil {
+			return dbError(err)
+		}
+		return nil
+	})
+}
+
+// ChangeEmailCommand 修改邮箱的命令
+type ChangeEmailCommand struct {
+	UserID   uint64
+	NewEmail string
+}
+
+// ChangeEmail: 申请修改邮箱，返回验证令牌，由调用方发送到新邮箱
+// 新邮箱在 ConfirmEmailChange 确认之前不生效；同一事务中取消该用户之前未确认的请求，只有最新的令牌有效
+func (u *UserAppService) ChangeEmail(ctx context.Context, cmd ChangeEmailCommand) (string, error) {
+	if u.emailChangeRepo == nil {
+		return "", models.ErrNotEnabled.With("feature", "email_change")
+	}
+	user, err := u.userRepo.FindByID(ctx, cmd.UserID)
+	if err != nil {
+		return "", notFound(err, models.ErrUserNotFound.With("user_id", cmd.UserID))
+	}
+
+	req, token, err := models.NewEmailChangeRequest(user, cmd.NewEmail, u.emailPolicy, u.emailChangeTTL, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err := u.checkEmailAvailable(ctx, req.CanonicalEmail, cmd.NewEmail); err != nil {
+		return "", err
+	}
+
+	err = u.txManager.Transaction(ctx, func(txCtx context.Context) error {
+		if _, err := u.emailChangeRepo.CancelPending(txCtx, user.ID); err != nil {
+			return dbError(err)
+		}
+		if _, err := u.emailChangeRepo.Save(txCtx, req); err != nil {
+			return dbError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConfirmEmailChangeCommand 确认修改邮箱的命令
+type ConfirmEmailChangeCommand struct {
+	Token string
+}
+
+// ConfirmEmailChange: 使用令牌确认修改邮箱，确认与邮箱更新在同一事务中完成
+func (u *UserAppService) ConfirmEmailChange(ctx context.Context, cmd ConfirmEmailChangeCommand) error {
+	if u.emailChangeRepo == nil {
+		return models.ErrNotEnabled.With("feature", "email_change")
+	}
+	req, err := u.emailChangeRepo.FindByTokenHash(ctx, models.HashEmailChangeToken(cmd.Token))
+	if err != nil {
+		return notFound(err, models.ErrEmailChangeTokenNotFound)
+	}
+	if err := req.Confirm(time.Now()); err != nil {
+		return err
+	}
+
+	return retryOnConflict(func() error {
+		return u.txManager.Transaction(ctx, func(txCtx context.Context) error {
+			user, err := u.userRepo.FindByID(txCtx, req.UserID)
+			if err != nil {
+				return notFound(err, models.ErrUserNotFound.With("user_id", req.UserID))
+			}
+			if err := user.ApplyEmailChange(req); err != nil {
+				return err
+			}
+			if err := u.emailChangeRepo.MarkConfirmed(txCtx, req); err != nil {
+				return dbError(err)
+			}
+			// 申请之后新邮箱可能已被其他用户注册，由唯一索引兜底
+			_, err = u.userRepo.UpdateFields(txCtx, user, "Email", "CanonicalEmail")
+			if errors.Is(err, repositories.ErrorDuplicate) {
+				return models.ErrEmailTaken.With("email", req.NewEmail).Wrap(err)
+			} else if err != nil {
+				return dbError(err)
+			}
+			return nil
+		})
+	})
+}
+
+// checkEmailAvailable 规范化邮箱未被其他用户使用
+func (u *UserAppService) checkEmailAvailable(ctx context.Context, canonical string, email string) error {
+	exist_user, err := u.userRepo.FindByEmail(ctx, canonical)
+	if err != nil && !errors.Is(err, repositories.ErrorNotFound) {
+		return dbError(err)
+	}
+	if exist_user != nil {
+		return models.ErrEmailTaken.With("email", email).Wrap(repositories.ErrorDuplicate)
+	}
+	return nil
+}
+
+// SuspendUser: 停用用户，停用后不能下单
+func (u *UserAppService) SuspendUser(ctx context.Context, userID uint64) error {
+	return u.changeUserStatus(ctx, userID, (*models.User).Suspend)
+}
+
+// ReactivateUser: 恢复已停用的用户
+func (u *UserAppService) ReactivateUser(ctx context.Context, userID uint64) error {
+	return u.changeUserStatus(ctx, userID, (*models.User).Reactivate)
+}
+
+// DeleteUser: 注销用户（软删除），用户的订单保留，之后默认的查询不再返回该用户
+func (u *UserAppService) DeleteUser(ctx context.Context, userID uint64) error {
+	return u.changeUserStatus(ctx, userID, func(user *models.User) error {
+		return user.Delete(time.Now())
+	})
+}
+
+// SetSpendingLimitsCommand 调整用户消费限额的命令（基准币种），为空的限额恢复使用默认值，为0表示不限制
+type SetSpendingLimitsCommand struct {
+	UserID         uint64
+	MaxOrderAmount *models.Money // 单笔订单上限
+	RollingCap     *models.Money // 最近30天累计上限
+}
+
+// SetSpendingLimits: 调整用户的消费限额，之后的下单按新限额检查
+func (u *UserAppService) SetSpendingLimits(ctx context.Context, cmd SetSpendingLimitsCommand) error {
+	return retryOnConflict(func() error {
+		user, err := u.userRepo.FindByID(ctx, cmd.UserID)
+		if err != nil {
+			return notFound(err, models.ErrUserNotFound.With("user_id", cmd.UserID))
+		}
+		if err := user.SetSpendingLimits(cmd.MaxOrderAmount, cmd.RollingCap); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.UpdateFields(ctx, user, "MaxOrderAmount", "RollingSpendingCap"); err != nil {
+			return dbError(err)
+		}
+		return nil
+	})
+}
+
+// changeUserStatus 读取用户并执行 change，只写入状态与删除时间
+func (u *UserAppService) changeUserStatus(ctx context.Context, userID uint64, change func(user *models.User) error) error {
+	return retryOnConflict(func() error {
+		user, err := u.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return notFound(err, models.ErrUserNotFound.With("user_id", userID))
+		}
+		if err := change(user); err != nil {
+			return err
+		}
+		if _, err := u.userRepo.UpdateFields(ctx, user, "Status", "DeletedAt"); err != nil {
+			return dbError(err)
+		}
+		return nil
+	})
+}
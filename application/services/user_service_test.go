@@ -1,9 +1,11 @@
 package services_test
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
 
@@ -30,7 +32,7 @@ func TestCreateNewUser_Success(t *testing.T) {
 
 		// 模拟 FindByEmail 返回无用户
 		mockUserRepo.EXPECT().
-			FindByEmail(cmd.Email).
+			FindByEmail(gomock.Any(), cmd.Email).
 			Return(nil, repositories.ErrorNotFound)
 
 		// 这里不验证models.CreateUser是因为:
@@ -39,16 +41,16 @@ func TestCreateNewUser_Success(t *testing.T) {
 
 		// 模拟 Save 方法
 		mockUserRepo.EXPECT().
-			Save(gomock.Any()).
-			Do(func(user *models.User) {
+			Save(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, user *models.User) {
 				assert.Equal(t, cmd.Name, user.Name)
 				assert.Equal(t, cmd.Email, user.Email)
-				assert.Equal(t, float64(0), user.TotalConsumption)
+				assert.Equal(t, models.MustParseMoney("0"), user.TotalConsumption)
 			}).
 			Return(uint64(1001), nil) // 返回模拟的用户ID
 
 		// 执行测试
-		userID, err := service.CreateNewUser(cmd)
+		userID, err := service.CreateNewUser(context.Background(), cmd)
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(1001), userID)
 	})
@@ -68,11 +70,12 @@ func TestCreateNewUser_ExistedEmail(t *testing.T) {
 			Email: "existed@example.com", // 有效邮箱
 		}
 
-		mockUserRepo.EXPECT().FindByEmail(cmd.Email).
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), cmd.Email).
 			Return(&models.User{ID: 1001}, nil)
 		// 执行
-		_, err := service.CreateNewUser(cmd)
-		assert.ErrorContains(t, err, fmt.Sprintf("邮箱%s已存在", cmd.Email))
+		_, err := service.CreateNewUser(context.Background(), cmd)
+		assert.ErrorIs(t, err, models.ErrEmailTaken)
+		assert.Equal(t, models.CategoryConflict, models.CategoryOf(err))
 	})
 }
 
@@ -90,10 +93,10 @@ func TestCreateNewUser_DBError(t *testing.T) {
 			Email: "error@example.com", // 有效邮箱
 		}
 
-		mockUserRepo.EXPECT().FindByEmail(cmd.Email).
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), cmd.Email).
 			Return(nil, errors.New("connection error"))
 		// 执行
-		_, err := service.CreateNewUser(cmd)
+		_, err := service.CreateNewUser(context.Background(), cmd)
 		assert.ErrorContains(t, err, "connection error")
 	})
 }
@@ -112,10 +115,10 @@ func TestCreateNewUser_ParamError(t *testing.T) {
 			Email: "invalid email", // 有效邮箱
 		}
 
-		mockUserRepo.EXPECT().FindByEmail(cmd.Email).
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), cmd.Email).
 			Return(nil, errors.New("邮件格式不正确"))
 		// 执行
-		_, err := service.CreateNewUser(cmd)
+		_, err := service.CreateNewUser(context.Background(), cmd)
 		assert.ErrorContains(t, err, "邮件格式不正确")
 	})
 }
@@ -134,14 +137,386 @@ func TestCreateNewUser_SaveError(t *testing.T) {
 			Email: "test@example.com", // 有效邮箱
 		}
 
-		mockUserRepo.EXPECT().FindByEmail(cmd.Email).
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), cmd.Email).
 			Return(nil, nil)
 
-		mockUserRepo.EXPECT().Save(gomock.Any()).
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
 			Return(uint64(0), errors.New("disk full"))
 
 		// 执行
-		_, err := service.CreateNewUser(cmd)
+		_, err := service.CreateNewUser(context.Background(), cmd)
 		assert.ErrorContains(t, err, "disk full")
 	})
 }
+
+func TestCreateNewUser_EmailPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	policy := models.NewEmailPolicy(models.WithAllowedDomains("corp.com"))
+	service := services.NewUserAppService(mockUserRepo, services.WithEmailPolicy(policy))
+
+	t.Run("企业邮箱可以注册", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "alice@corp.com").Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(1002), nil)
+
+		userID, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{
+			Name: "Alice", Email: "alice@corp.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1002), userID)
+	})
+
+	t.Run("不在允许名单中的域名", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "bob@qq.com").Return(nil, repositories.ErrorNotFound)
+
+		_, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{
+			Name: "Bob", Email: "bob@qq.com"})
+		assert.ErrorIs(t, err, models.ErrInvalidEmail)
+	})
+}
+
+func TestCreateNewUser_CaseInsensitiveEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo)
+
+	t.Run("按规范化邮箱查询已存在的用户", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(&models.User{ID: 1001}, nil)
+
+		userID, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{
+			Name: "A", Email: "A@QQ.com"})
+		assert.ErrorIs(t, err, models.ErrEmailTaken)
+		assert.Equal(t, uint64(1001), userID)
+	})
+
+	t.Run("保存时保留展示用的邮箱", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, user *models.User) {
+				assert.Equal(t, "A@qq.com", user.Email)
+				assert.Equal(t, "a@qq.com", user.CanonicalEmail)
+			}).Return(uint64(1002), nil)
+
+		_, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{
+			Name: "A", Email: "A@qq.com"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("并发注册同一邮箱时唯一索引冲突", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "a@qq.com").Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), repositories.ErrorDuplicate)
+
+		_, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{
+			Name: "A", Email: "a@qq.com"})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo)
+
+	t.Run("只更新用户名", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).
+			Return(&models.User{ID: 1001, Name: "old", Email: "a@qq.com"}, nil)
+		mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Name").
+			Do(func(_ context.Context, user *models.User, _ ...string) {
+				assert.Equal(t, "new", user.Name)
+			}).Return(int8(1), nil)
+
+		err := service.UpdateUserProfile(context.Background(), services.UpdateUserProfileCommand{UserID: 1001, Name: " new "})
+		assert.NoError(t, err)
+	})
+
+	t.Run("用户名未变化时不写入", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Name: "same"}, nil)
+
+		err := service.UpdateUserProfile(context.Background(), services.UpdateUserProfileCommand{UserID: 1001, Name: "same"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("版本冲突时重新读取后重试", func(t *testing.T) {
+		gomock.InOrder(
+			mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Name: "old"}, nil),
+			mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Name").Return(int8(0), repositories.ErrorVersionConflict),
+			mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Name: "old", Version: 1}, nil),
+			mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Name").Return(int8(1), nil),
+		)
+
+		err := service.UpdateUserProfile(context.Background(), services.UpdateUserProfileCommand{UserID: 1001, Name: "new"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("用户名过长", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Name: "old"}, nil)
+
+		err := service.UpdateUserProfile(context.Background(), services.UpdateUserProfileCommand{
+			UserID: 1001, Name: strings.Repeat("名", 101)})
+		assert.ErrorIs(t, err, models.ErrInvalidUserName)
+	})
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockEmailChangeRepo := mocks.NewMockEmailChangeRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo, services.WithEmailChange(mockEmailChangeRepo, mockTxManager, time.Hour))
+	user := func() *models.User {
+		return &models.User{ID: 1001, Name: "a", Email: "a@qq.com", CanonicalEmail: "a@qq.com", Version: 2}
+	}
+
+	t.Run("申请修改邮箱时只保存令牌摘要，不修改用户", func(t *testing.T) {
+		var saved *models.EmailChangeRequest
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "b@corp.com").Return(nil, repositories.ErrorNotFound)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				cancel := mockEmailChangeRepo.EXPECT().CancelPending(gomock.Any(), uint64(1001)).Return(int64(1), nil)
+				mockEmailChangeRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+					Do(func(_ context.Context, req *models.EmailChangeRequest) { saved = req }).
+					Return(uint64(1), nil).After(cancel)
+				return fn(ctx)
+			})
+
+		token, err := service.ChangeEmail(context.Background(), services.ChangeEmailCommand{UserID: 1001, NewEmail: "B@corp.com"})
+		assert.NoError(t, err)
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, "B@corp.com", saved.NewEmail)
+			assert.Equal(t, "b@corp.com", saved.CanonicalEmail)
+			assert.Equal(t, models.HashEmailChangeToken(token), saved.TokenHash)
+			assert.NotEqual(t, token, saved.TokenHash)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), saved.ExpiresAt, time.Minute)
+		}
+	})
+
+	t.Run("取消之前的请求失败时不保存新请求", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "b@corp.com").Return(nil, repositories.ErrorNotFound)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockEmailChangeRepo.EXPECT().CancelPending(gomock.Any(), uint64(1001)).Return(int64(0), errors.New("db error"))
+				return fn(ctx)
+			})
+
+		token, err := service.ChangeEmail(context.Background(), services.ChangeEmailCommand{UserID: 1001, NewEmail: "b@corp.com"})
+		assert.ErrorIs(t, err, models.ErrInternal)
+		assert.Empty(t, token)
+	})
+
+	t.Run("新邮箱已被其他用户使用", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "b@corp.com").Return(&models.User{ID: 1002}, nil)
+
+		_, err := service.ChangeEmail(context.Background(), services.ChangeEmailCommand{UserID: 1001, NewEmail: "b@corp.com"})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	t.Run("新邮箱与当前邮箱相同", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+
+		_, err := service.ChangeEmail(context.Background(), services.ChangeEmailCommand{UserID: 1001, NewEmail: "A@qq.com"})
+		assert.ErrorIs(t, err, models.ErrSameEmail)
+	})
+
+	t.Run("确认后新邮箱生效", func(t *testing.T) {
+		req := &models.EmailChangeRequest{ID: 1, UserID: 1001, NewEmail: "B@corp.com", CanonicalEmail: "b@corp.com",
+			ExpiresAt: time.Now().Add(time.Hour)}
+		mockEmailChangeRepo.EXPECT().FindByTokenHash(gomock.Any(), models.HashEmailChangeToken("token")).Return(req, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+				mockEmailChangeRepo.EXPECT().MarkConfirmed(gomock.Any(), req).Return(nil)
+				mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Email", "CanonicalEmail").
+					Do(func(_ context.Context, user *models.User, _ ...string) {
+						assert.Equal(t, "B@corp.com", user.Email)
+						assert.Equal(t, "b@corp.com", user.CanonicalEmail)
+					}).Return(int8(1), nil)
+				return fn(ctx)
+			})
+
+		err := service.ConfirmEmailChange(context.Background(), services.ConfirmEmailChangeCommand{Token: "token"})
+		assert.NoError(t, err)
+		assert.NotNil(t, req.ConfirmedAt)
+	})
+
+	t.Run("令牌已过期", func(t *testing.T) {
+		req := &models.EmailChangeRequest{ID: 2, UserID: 1001, ExpiresAt: time.Now().Add(-time.Second)}
+		mockEmailChangeRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(req, nil)
+
+		err := service.ConfirmEmailChange(context.Background(), services.ConfirmEmailChangeCommand{Token: "expired"})
+		assert.ErrorIs(t, err, models.ErrEmailChangeExpired)
+	})
+
+	t.Run("令牌无效", func(t *testing.T) {
+		mockEmailChangeRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(nil, repositories.ErrorNotFound)
+
+		err := service.ConfirmEmailChange(context.Background(), services.ConfirmEmailChangeCommand{Token: "unknown"})
+		assert.ErrorIs(t, err, models.ErrEmailChangeTokenNotFound)
+	})
+
+	t.Run("确认时新邮箱已被注册则整体回滚", func(t *testing.T) {
+		req := &models.EmailChangeRequest{ID: 3, UserID: 1001, NewEmail: "c@corp.com", CanonicalEmail: "c@corp.com",
+			ExpiresAt: time.Now().Add(time.Hour)}
+		mockEmailChangeRepo.EXPECT().FindByTokenHash(gomock.Any(), gomock.Any()).Return(req, nil)
+		mockTxManager.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+				mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(user(), nil)
+				mockEmailChangeRepo.EXPECT().MarkConfirmed(gomock.Any(), req).Return(nil)
+				mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Email", "CanonicalEmail").
+					Return(int8(0), repositories.ErrorDuplicate)
+				return fn(ctx)
+			})
+
+		err := service.ConfirmEmailChange(context.Background(), services.ConfirmEmailChangeCommand{Token: "token"})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+}
+
+func TestUserStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo)
+
+	t.Run("停用用户", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Status: models.UserActive}, nil)
+		mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Status", "DeletedAt").
+			Do(func(_ context.Context, user *models.User, _ ...string) {
+				assert.Equal(t, models.UserSuspended, user.Status)
+				assert.False(t, user.DeletedAt.Valid)
+			}).Return(int8(1), nil)
+
+		assert.NoError(t, service.SuspendUser(context.Background(), 1001))
+	})
+
+	t.Run("恢复用户", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Status: models.UserSuspended}, nil)
+		mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Status", "DeletedAt").Return(int8(1), nil)
+
+		assert.NoError(t, service.ReactivateUser(context.Background(), 1001))
+	})
+
+	t.Run("注销用户时写入删除时间", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Status: models.UserActive}, nil)
+		mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "Status", "DeletedAt").
+			Do(func(_ context.Context, user *models.User, _ ...string) {
+				assert.Equal(t, models.UserDeleted, user.Status)
+				assert.True(t, user.DeletedAt.Valid)
+			}).Return(int8(1), nil)
+
+		assert.NoError(t, service.DeleteUser(context.Background(), 1001))
+	})
+
+	t.Run("已注销的用户查询不到", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1002)).Return(nil, repositories.ErrorNotFound)
+
+		assert.ErrorIs(t, service.DeleteUser(context.Background(), 1002), repositories.ErrorNotFound)
+	})
+
+	t.Run("非法的状态变更不写入", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Status: models.UserActive}, nil)
+
+		assert.Error(t, service.ReactivateUser(context.Background(), 1001))
+	})
+}
+
+func TestSetSpendingLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo)
+
+	t.Run("设置单笔上限，滚动上限恢复默认", func(t *testing.T) {
+		maxOrder := models.MustParseMoney("5000")
+		oldCap := models.MustParseMoney("100")
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, RollingSpendingCap: &oldCap}, nil)
+		mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "MaxOrderAmount", "RollingSpendingCap").
+			Do(func(_ context.Context, user *models.User, _ ...string) {
+				if assert.NotNil(t, user.MaxOrderAmount) {
+					assert.Equal(t, maxOrder, *user.MaxOrderAmount)
+				}
+				assert.Nil(t, user.RollingSpendingCap)
+			}).Return(int8(1), nil)
+
+		assert.NoError(t, service.SetSpendingLimits(context.Background(), services.SetSpendingLimitsCommand{
+			UserID:         1001,
+			MaxOrderAmount: &maxOrder,
+		}))
+	})
+
+	t.Run("负数限额不写入", func(t *testing.T) {
+		negative := models.MustParseMoney("-1")
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001}, nil)
+
+		assert.Error(t, service.SetSpendingLimits(context.Background(), services.SetSpendingLimitsCommand{
+			UserID:     1001,
+			RollingCap: &negative,
+		}))
+	})
+
+	t.Run("版本冲突时重试", func(t *testing.T) {
+		gomock.InOrder(
+			mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Version: 1}, nil),
+			mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "MaxOrderAmount", "RollingSpendingCap").
+				Return(int8(0), repositories.ErrorVersionConflict),
+			mockUserRepo.EXPECT().FindByID(gomock.Any(), uint64(1001)).Return(&models.User{ID: 1001, Version: 2}, nil),
+			mockUserRepo.EXPECT().UpdateFields(gomock.Any(), gomock.Any(), "MaxOrderAmount", "RollingSpendingCap").
+				Return(int8(1), nil),
+		)
+
+		assert.NoError(t, service.SetSpendingLimits(context.Background(), services.SetSpendingLimitsCommand{UserID: 1001}))
+	})
+}
+
+func TestCreateNewUser_DomainEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockTxManager := mocks.NewMockTransactionManager(ctrl)
+	mockDispatcher := mocks.NewMockEventDispatcher(ctrl)
+
+	service := services.NewUserAppService(mockUserRepo, services.WithUserEventDispatcher(mockDispatcher, mockTxManager))
+
+	t.Run("保存成功后分发注册事件", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "alice@example.com").Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, user *models.User) (uint64, error) {
+				user.ID = 1001
+				return user.ID, nil
+			})
+		// 不在事务中时立即执行
+		mockTxManager.EXPECT().AfterCommit(gomock.Any(), gomock.Any()).
+			Do(func(ctx context.Context, fn func(context.Context)) { fn(ctx) })
+		mockDispatcher.EXPECT().Dispatch(gomock.Any(), models.UserRegistered{UserID: 1001, Email: "Alice@example.com"}).Return(nil)
+
+		userID, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{Name: "Alice", Email: "Alice@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), userID)
+	})
+
+	t.Run("保存失败时不分发", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByEmail(gomock.Any(), "bob@example.com").Return(nil, repositories.ErrorNotFound)
+		mockUserRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(uint64(0), repositories.ErrorDuplicate)
+
+		_, err := service.CreateNewUser(context.Background(), services.CreateNewUserCommand{Name: "Bob", Email: "bob@example.com"})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计操作
+const (
+	AuditUserErased = "UserErased"
+)
+
+// AuditEntry 审计记录：谁在什么时候对哪个对象做了什么，Detail 中不得包含个人信息
+type AuditEntry struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement"`
+	Actor       string    `gorm:"type:varchar(100);not null"` // 操作人（管理员账号、系统任务名等）
+	Action      string    `gorm:"type:varchar(50);not null"`
+	SubjectType string    `gorm:"type:varchar(50);not null;index:idx_audit_subject,priority:1"` // 同 OutboxMessage.AggregateType
+	SubjectID   uint64    `gorm:"not null;index:idx_audit_subject,priority:2"`
+	Detail      string    `gorm:"type:text;not null"` // JSON
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// NewAuditEntry: 创建审计记录，detail 序列化为JSON
+func NewAuditEntry(actor string, action string, subjectType string, subjectID uint64, detail interface{}) (*AuditEntry, error) {
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditEntry{
+		Actor:       actor,
+		Action:      action,
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Detail:      string(data),
+	}, nil
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Currency ISO 4217 币种代码
+type Currency string
+
+const (
+	CNY Currency = "CNY"
+	USD Currency = "USD"
+	JPY Currency = "JPY"
+)
+
+// BaseCurrency 基准（报表）币种，users.total_consumption 始终以该币种计
+const BaseCurrency = CNY
+
+// ParseCurrency 解析币种代码（不区分大小写），空字符串视为基准币种
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if c == "" {
+		return BaseCurrency, nil
+	}
+	switch c {
+	case CNY, USD, JPY:
+		return c, nil
+	}
+	return "", ErrUnsupportedCurrency.With("currency", code)
+}
+
+// Exponent 币种的小数位数（ISO 4217 的辅币单位位数），如 JPY 没有辅币单位，为0
+// 不超过 Money 的两位小数
+func (c Currency) Exponent() int {
+	if c == JPY {
+		return 0
+	}
+	return 2
+}
+
+// CheckAmount 以该币种计价的金额，小数位数不能超过 Exponent，如 JPY 100.50 不合法
+func (c Currency) CheckAmount(m Money) error {
+	unit := int64(1)
+	for i := c.Exponent(); i < 2; i++ {
+		unit *= 10
+	}
+	if m.cents%unit != 0 {
+		return ErrInvalidAmount.With("reason", "currency_scale").With("amount", m).
+			With("currency", c).With("exponent", c.Exponent())
+	}
+	return nil
+}
+
+// 汇率与数据库列 decimal(18,8) 对应
+const (
+	ratePlaces = 8
+	rateScale  = 100000000
+)
+
+// Rate 汇率值，内部以 1e-8 为单位的整数保存
+type Rate struct {
+	units int64
+}
+
+// RateOne 汇率 1，基准币种订单使用
+var RateOne = Rate{units: rateScale}
+
+// ParseRate 解析十进制字符串形式的汇率，如 "7.1234"，超过8位小数时四舍五入
+func ParseRate(s string) (Rate, error) {
+	units, err := parseFixed(s, ratePlaces)
+	if err != nil {
+		return Rate{}, err
+	}
+	if units <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{units: units}, nil
+}
+
+// MustParseRate 同 ParseRate，解析失败时 panic，仅用于常量与测试
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r Rate) IsZero() bool { return r.units == 0 }
+
+// Convert 按汇率换算金额，结果保留两位小数，四舍五入（远离零）
+func (r Rate) Convert(m Money) (Money, error) {
+	if r.units <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+	product := new(big.Int).Mul(big.NewInt(m.cents), big.NewInt(r.units))
+	quo, rem := new(big.Int).QuoRem(product, big.NewInt(rateScale), new(big.Int))
+	// |rem| * 2 >= scale 时远离零进位
+	if new(big.Int).Abs(rem).Cmp(big.NewInt(rateScale/2)) >= 0 {
+		if product.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return checkedMoney(quo.Int64())
+}
+
+// String 八位小数的十进制表示，如 "7.12340000"
+func (r Rate) String() string {
+	return fmt.Sprintf("%d.%08d", r.units/rateScale, r.units%rateScale)
+}
+
+// Value 实现 driver.Valuer，以十进制字符串写入 decimal 列
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan 实现 sql.Scanner
+func (r *Rate) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*r = Rate{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("无法将 %T 转换为汇率", src)
+	}
+	units, err := parseFixed(s, ratePlaces)
+	if err != nil {
+		return err
+	}
+	*r = Rate{units: units}
+	return nil
+}
+
+// MarshalJSON 输出为JSON数字
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON 接受JSON数字或字符串
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	parsed, err := ParseRate(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// ExchangeRate 某币种折合基准币种的汇率，自 EffectiveAt 起生效，直到下一条同币种汇率生效
+type ExchangeRate struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement"`
+	Currency    Currency  `gorm:"type:char(3);not null;uniqueIndex:idx_rate_currency_effective,priority:1"`
+	Rate        Rate      `gorm:"type:decimal(18,8);not null;comment:1单位该币种折合基准币种的金额"`
+	EffectiveAt time.Time `gorm:"not null;uniqueIndex:idx_rate_currency_effective,priority:2"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// BaseExchangeRate 基准币种对自身的汇率
+func BaseExchangeRate() *ExchangeRate {
+	return &ExchangeRate{Currency: BaseCurrency, Rate: RateOne}
+}
+
+// Convert 把该币种的金额换算为基准币种
+func (e *ExchangeRate) Convert(amount Money) (Money, error) {
+	return e.Rate.Convert(amount)
+}
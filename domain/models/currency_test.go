@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+	for in, want := range map[string]Currency{"": BaseCurrency, "cny": CNY, " USD ": USD, "JPY": JPY} {
+		got, err := ParseCurrency(in)
+		if err != nil || got != want {
+			t.Errorf("%q: 期望 %s，实际 %s %v", in, want, got, err)
+		}
+	}
+	if _, err := ParseCurrency("EUR"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("期望不支持的币种错误，实际 %v", err)
+	}
+}
+
+func TestCurrency_CheckAmount(t *testing.T) {
+	cases := []struct {
+		currency Currency
+		amount   string
+		valid    bool
+	}{
+		{CNY, "100.55", true},
+		{USD, "0.01", true},
+		{JPY, "100", true},
+		{JPY, "-100", true},
+		{JPY, "100.50", false},
+		{JPY, "0.01", false},
+	}
+	for _, c := range cases {
+		err := c.currency.CheckAmount(MustParseMoney(c.amount))
+		if c.valid && err != nil {
+			t.Errorf("%s %s: 期望合法，实际 %v", c.currency, c.amount, err)
+		}
+		if !c.valid && !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("%s %s: 期望金额不合法，实际 %v", c.currency, c.amount, err)
+		}
+	}
+	if JPY.Exponent() != 0 || CNY.Exponent() != 2 || USD.Exponent() != 2 {
+		t.Errorf("币种小数位数错误")
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	cases := []struct {
+		rate, amount, want string
+	}{
+		{"1", "123.45", "123.45"},
+		{"7.1234", "10.01", "71.31"}, // 71.305234
+		{"0.04821", "1000", "48.21"}, // JPY
+		{"0.5", "0.01", "0.01"},      // 0.005 进位
+		{"0.5", "-0.01", "-0.01"},    // 远离零
+		{"0.33333333", "3", "1.00"},  // 0.99999999
+		{"0.00000001", "9999999999.99", "100.00"},
+	}
+	for _, c := range cases {
+		got, err := MustParseRate(c.rate).Convert(MustParseMoney(c.amount))
+		if err != nil {
+			t.Errorf("%s * %s: %v", c.amount, c.rate, err)
+			continue
+		}
+		if got != MustParseMoney(c.want) {
+			t.Errorf("%s * %s: 期望 %s，实际 %s", c.amount, c.rate, c.want, got)
+		}
+	}
+
+	if _, err := MustParseRate("2").Convert(MustParseMoney("9999999999.99")); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("期望溢出错误，实际 %v", err)
+	}
+	if _, err := (Rate{}).Convert(MustParseMoney("1")); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("期望汇率错误，实际 %v", err)
+	}
+	for _, in := range []string{"0", "-1"} {
+		if _, err := ParseRate(in); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("%q: 期望汇率错误，实际 %v", in, err)
+		}
+	}
+}
+
+func TestRate_ScanValue(t *testing.T) {
+	v, err := MustParseRate("7.1234").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "7.12340000" {
+		t.Errorf("期望 7.12340000，实际 %v", v)
+	}
+
+	var r Rate
+	if err := r.Scan([]byte("0.04821000")); err != nil {
+		t.Fatal(err)
+	}
+	if r != MustParseRate("0.04821") {
+		t.Errorf("期望 0.04821，实际 %s", r)
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// EmailChangeRequest 待确认的邮箱变更：新邮箱只有在用户通过令牌确认后才生效
+// 只保存令牌的 SHA-256 摘要，数据库泄露时无法用于确认
+type EmailChangeRequest struct {
+	ID             uint64     `gorm:"primaryKey;autoIncrement"`
+	UserID         uint64     `gorm:"not null;index:idx_email_change_user_id"`
+	NewEmail       string     `gorm:"type:varchar(255);not null"`
+	CanonicalEmail string     `gorm:"type:varchar(255);not null"`
+	TokenHash      string     `gorm:"type:char(64);not null;uniqueIndex:idx_email_change_token"`
+	ExpiresAt      time.Time  `gorm:"not null"`
+	ConfirmedAt    *time.Time // 为空表示尚未确认
+	CreatedAt      time.Time  `gorm:"autoCreateTime"`
+}
+
+// NewEmailChangeRequest 为用户生成邮箱变更请求，返回请求与需要发送到新邮箱的令牌（明文只在此处出现）
+// 新邮箱按 policy 校验与规范化，与当前邮箱的规范形式相同时返回错误
+func NewEmailChangeRequest(user *User, newEmail string, policy EmailPolicy, ttl time.Duration, now time.Time) (*EmailChangeRequest, string, error) {
+	if policy == nil {
+		policy = DefaultEmailPolicy()
+	}
+	newEmail = strings.TrimSpace(newEmail)
+	if err := policy.Validate(newEmail); err != nil {
+		return nil, "", err
+	}
+	canonical := policy.Canonicalize(newEmail)
+	if canonical == user.CanonicalEmail {
+		return nil, "", ErrSameEmail
+	}
+	if ttl <= 0 {
+		return nil, "", ErrInvalidArgument.With("field", "ttl")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(buf)
+	return &EmailChangeRequest{
+		UserID:         user.ID,
+		NewEmail:       newEmail,
+		CanonicalEmail: canonical,
+		TokenHash:      HashEmailChangeToken(token),
+		ExpiresAt:      now.Add(ttl),
+	}, token, nil
+}
+
+// HashEmailChangeToken 令牌的摘要，按摘要查找变更请求
+func HashEmailChangeToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Confirm 确认邮箱变更；已确认或已过期时返回错误且不修改
+func (r *EmailChangeRequest) Confirm(now time.Time) error {
+	if r.ConfirmedAt != nil {
+		return ErrEmailChangeConfirmed
+	}
+	if !now.Before(r.ExpiresAt) {
+		return ErrEmailChangeExpired
+	}
+	r.ConfirmedAt = &now
+	return nil
+}
@@ -0,0 +1,58 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailChangeRequest(t *testing.T) {
+	user := &models.User{ID: 1001, Email: "a@qq.com", CanonicalEmail: "a@qq.com"}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("确认后生效", func(t *testing.T) {
+		req, token, err := models.NewEmailChangeRequest(user, " B@corp.com ", nil, time.Hour, now)
+		assert.NoError(t, err)
+		assert.Len(t, token, 64)
+		assert.Equal(t, models.HashEmailChangeToken(token), req.TokenHash)
+		assert.Equal(t, now.Add(time.Hour), req.ExpiresAt)
+
+		target := *user
+		assert.Error(t, target.ApplyEmailChange(req), "未确认时不能生效")
+		assert.NoError(t, req.Confirm(now.Add(59*time.Minute)))
+		assert.NoError(t, target.ApplyEmailChange(req))
+		assert.Equal(t, "B@corp.com", target.Email)
+		assert.Equal(t, "b@corp.com", target.CanonicalEmail)
+
+		assert.ErrorIs(t, req.Confirm(now), models.ErrEmailChangeConfirmed)
+	})
+
+	t.Run("令牌过期", func(t *testing.T) {
+		req, _, err := models.NewEmailChangeRequest(user, "b@corp.com", nil, time.Hour, now)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, req.Confirm(now.Add(time.Hour)), models.ErrEmailChangeExpired)
+		assert.Nil(t, req.ConfirmedAt)
+	})
+
+	t.Run("每次生成的令牌不同", func(t *testing.T) {
+		_, first, _ := models.NewEmailChangeRequest(user, "b@corp.com", nil, time.Hour, now)
+		_, second, _ := models.NewEmailChangeRequest(user, "b@corp.com", nil, time.Hour, now)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("新邮箱不合法或与当前邮箱相同", func(t *testing.T) {
+		_, _, err := models.NewEmailChangeRequest(user, "invalid", nil, time.Hour, now)
+		assert.ErrorIs(t, err, models.ErrInvalidEmail)
+		_, _, err = models.NewEmailChangeRequest(user, "A@QQ.com", nil, time.Hour, now)
+		assert.ErrorContains(t, err, "新邮箱与当前邮箱相同")
+	})
+
+	t.Run("不能用于其他用户", func(t *testing.T) {
+		req, _, _ := models.NewEmailChangeRequest(user, "b@corp.com", nil, time.Hour, now)
+		assert.NoError(t, req.Confirm(now))
+		other := &models.User{ID: 1002}
+		assert.Error(t, other.ApplyEmailChange(req))
+	})
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// ErrInvalidEmail 的详情 reason：邮箱不符合当前策略的原因
+const (
+	EmailReasonSyntax       = "syntax"        // 语法不正确
+	EmailReasonDeniedDomain = "denied_domain" // 域名在拒绝名单中或不在允许名单中
+	EmailReasonDisposable   = "disposable"    // 一次性邮箱
+	EmailReasonNoMX         = "no_mx"         // 域名没有MX记录
+)
+
+// EmailPolicy 注册时的邮箱校验与规范化策略
+type EmailPolicy interface {
+	Validate(email string) error
+	// Canonicalize 邮箱的规范形式，规范形式相同的邮箱视为同一个邮箱（唯一约束建立在规范形式上）
+	Canonicalize(email string) string
+}
+
+// LocalPartRule 邮箱本地部分（@之前）的额外规范化规则，domain 已转为小写
+type LocalPartRule func(local string, domain string) string
+
+// IgnorePlusTag 忽略 + 之后的标签，例如 alice+shop@corp.com 与 alice@corp.com 视为同一个邮箱
+func IgnorePlusTag(local string, domain string) string {
+	if i := strings.Index(local, "+"); i > 0 {
+		return local[:i]
+	}
+	return local
+}
+
+// IgnoreDotsFor 对指定域名忽略本地部分中的点（如 gmail.com）
+func IgnoreDotsFor(domains ...string) LocalPartRule {
+	domains = normalizeDomains(domains)
+	return func(local string, domain string) string {
+		if matchDomain(domain, domains) {
+			return strings.ReplaceAll(local, ".", "")
+		}
+		return local
+	}
+}
+
+// MXChecker 检查域名是否能接收邮件（存在MX记录），由基础设施层实现，测试中可替换为桩
+type MXChecker interface {
+	HasMX(domain string) (bool, error)
+}
+
+// DomainEmailPolicy 按 RFC 5322 校验语法，并按域名的允许、拒绝与一次性邮箱名单过滤
+// 名单中的域名同时匹配其子域名，例如 corp.com 匹配 mail.corp.com
+type DomainEmailPolicy struct {
+	allow      []string // 为空时不限制
+	deny       []string
+	disposable []string
+	mx         MXChecker // 可选，为空时不检查MX记录
+	localRules []LocalPartRule
+}
+
+// EmailPolicyOption DomainEmailPolicy 的可选规则
+type EmailPolicyOption func(*DomainEmailPolicy)
+
+// WithAllowedDomains 只允许这些域名注册
+func WithAllowedDomains(domains ...string) EmailPolicyOption {
+	return func(p *DomainEmailPolicy) {
+		p.allow = append(p.allow, normalizeDomains(domains)...)
+	}
+}
+
+// WithDeniedDomains 拒绝这些域名注册，优先于允许名单
+func WithDeniedDomains(domains ...string) EmailPolicyOption {
+	return func(p *DomainEmailPolicy) {
+		p.deny = append(p.deny, normalizeDomains(domains)...)
+	}
+}
+
+// WithDisposableDomains 拒绝一次性邮箱域名
+func WithDisposableDomains(domains ...string) EmailPolicyOption {
+	return func(p *DomainEmailPolicy) {
+		p.disposable = append(p.disposable, normalizeDomains(domains)...)
+	}
+}
+
+// WithMXCheck 要求邮箱域名存在MX记录
+func WithMXCheck(checker MXChecker) EmailPolicyOption {
+	return func(p *DomainEmailPolicy) {
+		p.mx = checker
+	}
+}
+
+// WithLocalPartRules 规范化邮箱时额外应用的本地部分规则
+func WithLocalPartRules(rules ...LocalPartRule) EmailPolicyOption {
+	return func(p *DomainEmailPolicy) {
+		p.localRules = append(p.localRules, rules...)
+	}
+}
+
+func NewEmailPolicy(opts ...EmailPolicyOption) *DomainEmailPolicy {
+	p := &DomainEmailPolicy{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// DefaultEmailPolicy 未配置策略时使用：只校验语法，规范形式不区分大小写
+func DefaultEmailPolicy() EmailPolicy {
+	return NewEmailPolicy()
+}
+
+// Validate 依次校验语法、拒绝名单、一次性邮箱、允许名单与MX记录
+func (p *DomainEmailPolicy) Validate(email string) error {
+	domain, err := emailDomain(email)
+	if err != nil {
+		return err
+	}
+	if matchDomain(domain, p.deny) {
+		return ErrInvalidEmail.With("reason", EmailReasonDeniedDomain).With("domain", domain)
+	}
+	if matchDomain(domain, p.disposable) {
+		return ErrInvalidEmail.With("reason", EmailReasonDisposable).With("domain", domain)
+	}
+	if len(p.allow) > 0 && !matchDomain(domain, p.allow) {
+		return ErrInvalidEmail.With("reason", EmailReasonDeniedDomain).With("domain", domain)
+	}
+	if p.mx != nil {
+		ok, err := p.mx.HasMX(domain)
+		if err != nil {
+			// 查询失败不是用户的错误
+			return ErrInternal.With("domain", domain).Wrap(err)
+		}
+		if !ok {
+			return ErrInvalidEmail.With("reason", EmailReasonNoMX).With("domain", domain)
+		}
+	}
+	return nil
+}
+
+// Canonicalize 去掉首尾空白并转为小写（绝大多数邮件服务的本地部分不区分大小写），再应用本地部分规则
+func (p *DomainEmailPolicy) Canonicalize(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	for _, rule := range p.localRules {
+		local = rule(local, domain)
+	}
+	return local + "@" + domain
+}
+
+// emailDomain 按 RFC 5322 的 addr-spec 校验邮箱（不接受显示名与尖括号），返回小写的域名
+func emailDomain(email string) (string, error) {
+	addr, err := mail.ParseAddress(email)
+	// 重新格式化后应与输入一致，排除显示名、注释与多余的空白
+	if err != nil || addr.Name != "" || (&mail.Address{Address: addr.Address}).String() != "<"+email+">" {
+		return "", ErrInvalidEmail.With("reason", EmailReasonSyntax)
+	}
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	// RFC 5321 的长度限制；域名必须是带点的主机名，不接受IP字面量
+	if len(local) > 64 || len(email) > 254 || !strings.Contains(domain, ".") || strings.HasPrefix(domain, "[") {
+		return "", ErrInvalidEmail.With("reason", EmailReasonSyntax)
+	}
+	return domain, nil
+}
+
+func matchDomain(domain string, list []string) bool {
+	for _, d := range list {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d != "" {
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,141 @@
+package models_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubMXChecker 测试用的MX检查，只有 domains 中的域名有MX记录
+type stubMXChecker struct {
+	domains map[string]bool
+	err     error
+}
+
+func (s stubMXChecker) HasMX(domain string) (bool, error) {
+	return s.domains[domain], s.err
+}
+
+func TestEmailPolicy_Syntax(t *testing.T) {
+	policy := models.DefaultEmailPolicy()
+
+	for _, email := range []string{
+		"test@example.com",
+		"first.last@corp.co.jp",
+		"user+tag@Sub.Example.org",
+		`"john doe"@example.com`,
+	} {
+		assert.NoError(t, policy.Validate(email), email)
+	}
+
+	for _, email := range []string{
+		"",
+		"invalid email",
+		"@example.com",
+		"test@",
+		"a@b@example.com",
+		"test@localhost",
+		"test@[127.0.0.1]",
+		"Test <test@example.com>",
+		"test..dot@example.com",
+	} {
+		assert.ErrorIs(t, policy.Validate(email), models.ErrInvalidEmail, email)
+	}
+}
+
+func TestEmailPolicy_Domains(t *testing.T) {
+	t.Run("允许名单匹配域名及其子域名", func(t *testing.T) {
+		policy := models.NewEmailPolicy(models.WithAllowedDomains("Corp.com", "@qq.com"))
+		assert.NoError(t, policy.Validate("a@corp.com"))
+		assert.NoError(t, policy.Validate("a@mail.CORP.com"))
+		assert.NoError(t, policy.Validate("a@qq.com"))
+		assert.ErrorIs(t, policy.Validate("a@notcorp.com"), models.ErrInvalidEmail)
+	})
+
+	t.Run("拒绝名单优先于允许名单", func(t *testing.T) {
+		policy := models.NewEmailPolicy(models.WithAllowedDomains("corp.com"), models.WithDeniedDomains("partner.corp.com"))
+		assert.NoError(t, policy.Validate("a@corp.com"))
+		assert.ErrorIs(t, policy.Validate("a@partner.corp.com"), models.ErrInvalidEmail)
+	})
+
+	t.Run("一次性邮箱", func(t *testing.T) {
+		policy := models.NewEmailPolicy(models.WithDisposableDomains("mailinator.com"))
+		err := policy.Validate("a@mailinator.com")
+		assert.ErrorIs(t, err, models.ErrInvalidEmail)
+		if domainErr, ok := models.AsDomainError(err); assert.True(t, ok) {
+			assert.Equal(t, models.EmailReasonDisposable, domainErr.Details["reason"])
+			assert.Equal(t, "mailinator.com", domainErr.Details["domain"])
+		}
+	})
+}
+
+func TestEmailPolicy_MX(t *testing.T) {
+	policy := models.NewEmailPolicy(models.WithMXCheck(stubMXChecker{domains: map[string]bool{"corp.com": true}}))
+	assert.NoError(t, policy.Validate("a@corp.com"))
+	assert.ErrorIs(t, policy.Validate("a@nomail.com"), models.ErrInvalidEmail)
+
+	t.Run("查询失败时不视为邮箱格式错误", func(t *testing.T) {
+		policy := models.NewEmailPolicy(models.WithMXCheck(stubMXChecker{err: errors.New("timeout")}))
+		err := policy.Validate("a@corp.com")
+		assert.ErrorContains(t, err, "timeout")
+		assert.NotErrorIs(t, err, models.ErrInvalidEmail)
+		assert.Equal(t, models.CategoryInternal, models.CategoryOf(err))
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	t.Run("未指定策略时只校验语法", func(t *testing.T) {
+		user, err := models.CreateUser("Test", " Test@Corp.com ", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test@Corp.com", user.Email)
+		assert.Equal(t, "test@corp.com", user.CanonicalEmail)
+	})
+
+	t.Run("按指定策略校验", func(t *testing.T) {
+		_, err := models.CreateUser("Test", "test@corp.com", models.NewEmailPolicy(models.WithDeniedDomains("corp.com")))
+		assert.ErrorIs(t, err, models.ErrInvalidEmail)
+	})
+}
+
+func TestEmailPolicy_Canonicalize(t *testing.T) {
+	t.Run("默认只去掉空白并转为小写", func(t *testing.T) {
+		policy := models.DefaultEmailPolicy()
+		assert.Equal(t, "a@qq.com", policy.Canonicalize(" A@QQ.com "))
+		assert.Equal(t, "alice+shop@corp.com", policy.Canonicalize("Alice+Shop@corp.com"))
+	})
+
+	t.Run("本地部分规则", func(t *testing.T) {
+		policy := models.NewEmailPolicy(models.WithLocalPartRules(models.IgnorePlusTag, models.IgnoreDotsFor("gmail.com")))
+		assert.Equal(t, "alice@corp.com", policy.Canonicalize("Alice+Shop@corp.com"))
+		assert.Equal(t, "first.last@corp.com", policy.Canonicalize("first.last@corp.com"))
+		assert.Equal(t, "firstlast@gmail.com", policy.Canonicalize("First.Last+news@Gmail.com"))
+		assert.Equal(t, "+tag@corp.com", policy.Canonicalize("+tag@corp.com"))
+	})
+}
+
+func TestNormalizeUserName(t *testing.T) {
+	t.Run("组合字符转为预组合字符", func(t *testing.T) {
+		// "e" + U+0301 与 U+00E9 规范化后相同
+		decomposed, err := models.NormalizeUserName(" Rene\u0301 ")
+		assert.NoError(t, err)
+		assert.Equal(t, "Ren\u00e9", decomposed)
+	})
+
+	t.Run("按字符数检查长度", func(t *testing.T) {
+		_, err := models.NormalizeUserName(strings.Repeat("名", 100))
+		assert.NoError(t, err)
+		_, err = models.NormalizeUserName(strings.Repeat("名", 101))
+		assert.ErrorContains(t, err, "100")
+		// 100 个组合序列规范化后为 100 个字符
+		_, err = models.NormalizeUserName(strings.Repeat("e\u0301", 100))
+		assert.NoError(t, err)
+	})
+
+	t.Run("用户名为空", func(t *testing.T) {
+		_, err := models.NormalizeUserName("  ")
+		assert.Error(t, err)
+	})
+}
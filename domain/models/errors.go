@@ -0,0 +1,250 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrorCategory 错误类别，调用方（如接口层）据此决定如何响应，不需要逐个判断错误码
+type ErrorCategory string
+
+const (
+	CategoryNotFound   ErrorCategory = "not_found"  // 对象不存在
+	CategoryConflict   ErrorCategory = "conflict"   // 与当前状态冲突，如重复、状态不允许、并发修改
+	CategoryValidation ErrorCategory = "validation" // 参数不合法
+	CategoryInternal   ErrorCategory = "internal"   // 数据库错误、配置错误等，调用方无法修正
+)
+
+// ErrorCode 稳定的错误码，文案调整时不变，调用方应按错误码而不是文案判断
+type ErrorCode string
+
+// DomainError 带错误码的领域错误
+// 下面定义的 Err* 是各错误码的原型，使用时通过 With / Wrap 生成附带详情与原因的副本：
+//
+//	return models.ErrUserNotFound.With("user_id", id).Wrap(err)
+//
+// errors.Is 按错误码比较，副本与原型视为同一错误；errors.As 可取出 *DomainError 读取详情
+type DomainError struct {
+	Code     ErrorCode
+	Category ErrorCategory
+	Message  string         // 默认的中文说明
+	Details  map[string]any // 说明中涉及的参数，如 user_id
+	Err      error          // 原因，可为空
+}
+
+// NewDomainError 定义新的错误码
+func NewDomainError(code ErrorCode, category ErrorCategory, message string) *DomainError {
+	return &DomainError{Code: code, Category: category, Message: message}
+}
+
+// Error 说明、按键排序的详情及原因，如 "用户不存在 (user_id=3): Not Found"
+func (e *DomainError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	if len(e.Details) > 0 {
+		keys := make([]string, 0, len(e.Details))
+		for key := range e.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString(" (")
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s=%v", key, e.Details[key])
+		}
+		b.WriteString(")")
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即视为同一错误
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == e.Code
+}
+
+// With 返回附带详情 key=value 的副本，原错误不变
+func (e *DomainError) With(key string, value any) *DomainError {
+	c := *e
+	c.Details = make(map[string]any, len(e.Details)+1)
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// Wrap 返回以 err 为原因的副本，原错误不变
+func (e *DomainError) Wrap(err error) *DomainError {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// AsDomainError 取出错误链中最外层的 *DomainError
+func AsDomainError(err error) (*DomainError, bool) {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
+// CategoryOf 错误的类别；不是领域错误时视为内部错误，err 为空时返回空
+func CategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+	if domainErr, ok := AsDomainError(err); ok {
+		return domainErr.Category
+	}
+	return CategoryInternal
+}
+
+// CodeOf 错误的错误码；不是领域错误时为 CodeInternal，err 为空时返回空
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	if domainErr, ok := AsDomainError(err); ok {
+		return domainErr.Code
+	}
+	return CodeInternal
+}
+
+// 错误码
+const (
+	CodeInternal        ErrorCode = "INTERNAL"
+	CodeNotEnabled      ErrorCode = "NOT_ENABLED"
+	CodeInvalidArgument ErrorCode = "INVALID_ARGUMENT"
+
+	CodeUserNotFound             ErrorCode = "USER_NOT_FOUND"
+	CodeUserNotActive            ErrorCode = "USER_NOT_ACTIVE"
+	CodeUserErased               ErrorCode = "USER_ERASED"
+	CodeInvalidUserName          ErrorCode = "INVALID_USER_NAME"
+	CodeInvalidStatusChange      ErrorCode = "INVALID_USER_STATUS_CHANGE"
+	CodeInsufficientConsumption  ErrorCode = "INSUFFICIENT_CONSUMPTION"
+	CodeInvalidEmail             ErrorCode = "INVALID_EMAIL"
+	CodeEmailTaken               ErrorCode = "EMAIL_TAKEN"
+	CodeSameEmail                ErrorCode = "SAME_EMAIL"
+	CodeEmailChangeTokenNotFound ErrorCode = "EMAIL_CHANGE_TOKEN_NOT_FOUND"
+	CodeEmailChangeExpired       ErrorCode = "EMAIL_CHANGE_EXPIRED"
+	CodeEmailChangeConfirmed     ErrorCode = "EMAIL_CHANGE_CONFIRMED"
+	CodeEmailChangeMismatch      ErrorCode = "EMAIL_CHANGE_MISMATCH"
+
+	CodeOrderNotFound          ErrorCode = "ORDER_NOT_FOUND"
+	CodeOrderInactive          ErrorCode = "ORDER_INACTIVE"
+	CodeInvalidTransition      ErrorCode = "INVALID_ORDER_TRANSITION"
+	CodeInvalidAmount          ErrorCode = "INVALID_AMOUNT"
+	CodeInvalidOrderItems      ErrorCode = "INVALID_ORDER_ITEMS"
+	CodeCurrencyMismatch       ErrorCode = "CURRENCY_MISMATCH"
+	CodeIdempotencyKeyConflict ErrorCode = "IDEMPOTENCY_KEY_CONFLICT"
+	CodeRefundExceedsPaid      ErrorCode = "REFUND_EXCEEDS_PAID"
+	CodeInvalidRefundReason    ErrorCode = "INVALID_REFUND_REASON"
+	CodeLimitExceeded          ErrorCode = "LIMIT_EXCEEDED"
+	CodeInvalidLimit           ErrorCode = "INVALID_LIMIT"
+
+	CodeProductNotFound ErrorCode = "PRODUCT_NOT_FOUND"
+	CodeSKUTaken        ErrorCode = "SKU_TAKEN"
+	CodeInvalidProduct  ErrorCode = "INVALID_PRODUCT"
+	CodeInvalidQuantity ErrorCode = "INVALID_QUANTITY"
+	CodeOutOfStock      ErrorCode = "OUT_OF_STOCK"
+
+	CodeUnsupportedCurrency  ErrorCode = "UNSUPPORTED_CURRENCY"
+	CodeInvalidRate          ErrorCode = "INVALID_RATE"
+	CodeExchangeRateNotFound ErrorCode = "EXCHANGE_RATE_NOT_FOUND"
+	CodeMoneyOverflow        ErrorCode = "MONEY_OVERFLOW"
+	CodeMoneyFormat          ErrorCode = "MONEY_FORMAT"
+
+	CodeInvalidTier        ErrorCode = "INVALID_TIER"
+	CodeTierThresholdTaken ErrorCode = "TIER_THRESHOLD_TAKEN"
+)
+
+// 通用错误
+var (
+	// ErrInternal 数据库错误等，原因见 Unwrap
+	ErrInternal = NewDomainError(CodeInternal, CategoryInternal, "内部错误")
+	// ErrNotEnabled 服务未配置相应的依赖，详情 feature 为功能名称
+	ErrNotEnabled = NewDomainError(CodeNotEnabled, CategoryInternal, "功能未启用")
+	// ErrInvalidArgument 参数不合法，详情 field 为参数名称
+	ErrInvalidArgument = NewDomainError(CodeInvalidArgument, CategoryValidation, "参数错误")
+)
+
+// 用户
+var (
+	ErrUserNotFound = NewDomainError(CodeUserNotFound, CategoryNotFound, "用户不存在")
+	// ErrUserNotActive 用户已停用或已注销，不能下单
+	ErrUserNotActive = NewDomainError(CodeUserNotActive, CategoryConflict, "用户不是正常状态")
+	ErrUserErased    = NewDomainError(CodeUserErased, CategoryConflict, "用户个人信息已抹除")
+	// ErrInvalidUserName 用户名为空或超过长度限制
+	ErrInvalidUserName = NewDomainError(CodeInvalidUserName, CategoryValidation, "用户名不合法")
+	// ErrInvalidStatusChange 当前状态的用户不能停用、恢复或注销
+	ErrInvalidStatusChange = NewDomainError(CodeInvalidStatusChange, CategoryConflict, "用户状态不允许该变更")
+	// ErrInsufficientConsumption 消费总额不能被扣减为负数
+	ErrInsufficientConsumption = NewDomainError(CodeInsufficientConsumption, CategoryConflict, "消费总额不足")
+	ErrInvalidEmail            = NewDomainError(CodeInvalidEmail, CategoryValidation, "邮箱格式不正确")
+	ErrEmailTaken              = NewDomainError(CodeEmailTaken, CategoryConflict, "邮箱已存在")
+	ErrSameEmail               = NewDomainError(CodeSameEmail, CategoryValidation, "新邮箱与当前邮箱相同")
+	// ErrEmailChangeTokenNotFound 邮箱变更的验证令牌无效
+	ErrEmailChangeTokenNotFound = NewDomainError(CodeEmailChangeTokenNotFound, CategoryNotFound, "验证令牌无效")
+	// ErrEmailChangeExpired 邮箱变更的验证令牌已过期
+	ErrEmailChangeExpired = NewDomainError(CodeEmailChangeExpired, CategoryConflict, "验证令牌已过期")
+	// ErrEmailChangeConfirmed 邮箱变更已确认过，令牌不能重复使用
+	ErrEmailChangeConfirmed = NewDomainError(CodeEmailChangeConfirmed, CategoryConflict, "验证令牌已使用")
+	// ErrEmailChangeMismatch 邮箱变更请求不属于该用户或尚未确认
+	ErrEmailChangeMismatch = NewDomainError(CodeEmailChangeMismatch, CategoryConflict, "邮箱变更请求不能应用到该用户")
+)
+
+// 订单
+var (
+	ErrOrderNotFound = NewDomainError(CodeOrderNotFound, CategoryNotFound, "订单不存在")
+	ErrOrderInactive = NewDomainError(CodeOrderInactive, CategoryConflict, "订单已失效")
+	// ErrInvalidTransition 订单状态不允许该变更
+	ErrInvalidTransition = NewDomainError(CodeInvalidTransition, CategoryConflict, "订单状态不允许该变更")
+	ErrInvalidAmount     = NewDomainError(CodeInvalidAmount, CategoryValidation, "金额不合法")
+	ErrInvalidOrderItems = NewDomainError(CodeInvalidOrderItems, CategoryValidation, "订单明细不合法")
+	// ErrCurrencyMismatch 商品币种与订单币种不一致
+	ErrCurrencyMismatch    = NewDomainError(CodeCurrencyMismatch, CategoryValidation, "币种不一致")
+	ErrRefundExceedsPaid   = NewDomainError(CodeRefundExceedsPaid, CategoryConflict, "退款金额超过订单可退金额")
+	ErrInvalidRefundReason = NewDomainError(CodeInvalidRefundReason, CategoryValidation, "退款原因不合法")
+	// ErrLimitExceeded 下单金额超过用户的消费限额；具体限额见 *LimitExceededError
+	ErrLimitExceeded = NewDomainError(CodeLimitExceeded, CategoryConflict, "超过消费限额")
+	ErrInvalidLimit  = NewDomainError(CodeInvalidLimit, CategoryValidation, "消费限额不能为负数")
+)
+
+// 商品
+var (
+	ErrProductNotFound = NewDomainError(CodeProductNotFound, CategoryNotFound, "商品不存在")
+	ErrSKUTaken        = NewDomainError(CodeSKUTaken, CategoryConflict, "SKU已存在")
+	ErrInvalidProduct  = NewDomainError(CodeInvalidProduct, CategoryValidation, "商品信息不合法")
+	ErrInvalidQuantity = NewDomainError(CodeInvalidQuantity, CategoryValidation, "数量必须大于0")
+	// ErrOutOfStock 库存不足；具体数量见 *OutOfStockError
+	ErrOutOfStock = NewDomainError(CodeOutOfStock, CategoryConflict, "库存不足")
+)
+
+// 币种与金额
+var (
+	ErrUnsupportedCurrency  = NewDomainError(CodeUnsupportedCurrency, CategoryValidation, "不支持的币种")
+	ErrInvalidRate          = NewDomainError(CodeInvalidRate, CategoryValidation, "汇率必须为正数")
+	ErrExchangeRateNotFound = NewDomainError(CodeExchangeRateNotFound, CategoryNotFound, "币种没有生效的汇率")
+	ErrMoneyOverflow        = NewDomainError(CodeMoneyOverflow, CategoryValidation, "金额超出范围")
+	ErrMoneyFormat          = NewDomainError(CodeMoneyFormat, CategoryValidation, "金额格式不正确")
+)
+
+// 客户等级
+var (
+	ErrInvalidTier        = NewDomainError(CodeInvalidTier, CategoryValidation, "等级门槛不合法")
+	ErrTierThresholdTaken = NewDomainError(CodeTierThresholdTaken, CategoryConflict, "门槛已被其他等级使用")
+)
@@ -0,0 +1,63 @@
+package models_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainError(t *testing.T) {
+	t.Run("副本与原型按错误码匹配", func(t *testing.T) {
+		cause := errors.New("record not found")
+		err := fmt.Errorf("查询失败: %w", models.ErrUserNotFound.With("user_id", uint64(3)).Wrap(cause))
+
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+		assert.ErrorIs(t, err, cause)
+		assert.NotErrorIs(t, err, models.ErrOrderNotFound)
+		assert.Equal(t, models.CodeUserNotFound, models.CodeOf(err))
+		assert.Equal(t, models.CategoryNotFound, models.CategoryOf(err))
+
+		domainErr, ok := models.AsDomainError(err)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(3), domainErr.Details["user_id"])
+	})
+
+	t.Run("With 不修改原型", func(t *testing.T) {
+		first := models.ErrInvalidArgument.With("field", "actor")
+		second := first.With("reason", "empty")
+
+		assert.Empty(t, models.ErrInvalidArgument.Details)
+		assert.Len(t, first.Details, 1)
+		assert.Len(t, second.Details, 2)
+		assert.Nil(t, models.ErrInvalidArgument.Wrap(errors.New("x")).Details)
+		assert.Nil(t, models.ErrInvalidArgument.Err)
+	})
+
+	t.Run("说明包含按键排序的详情与原因", func(t *testing.T) {
+		err := models.ErrInternal.With("table", "users").With("affected_rows", 0).Wrap(errors.New("db error"))
+		assert.EqualError(t, err, "内部错误 (affected_rows=0, table=users): db error")
+		assert.EqualError(t, models.ErrUserErased, "用户个人信息已抹除")
+	})
+
+	t.Run("非领域错误视为内部错误", func(t *testing.T) {
+		err := errors.New("connection refused")
+		assert.Equal(t, models.CategoryInternal, models.CategoryOf(err))
+		assert.Equal(t, models.CodeInternal, models.CodeOf(err))
+		assert.Empty(t, models.CategoryOf(nil))
+		assert.Empty(t, models.CodeOf(nil))
+	})
+
+	t.Run("库存不足与超过限额可以取出领域错误", func(t *testing.T) {
+		var err error = &models.LimitExceededError{Kind: models.LimitSingleOrder,
+			Limit: models.MustParseMoney("100"), Requested: models.MustParseMoney("150")}
+		assert.ErrorIs(t, err, models.ErrLimitExceeded)
+		assert.Equal(t, models.CategoryOf(models.ErrLimitExceeded), models.CategoryOf(err))
+
+		domainErr, ok := models.AsDomainError(err)
+		assert.True(t, ok)
+		assert.Equal(t, models.LimitSingleOrder, domainErr.Details["kind"])
+	})
+}
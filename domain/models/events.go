@@ -0,0 +1,72 @@
+package models
+
+// EventUserRegistered 用户注册事件类型
+const EventUserRegistered = "UserRegistered"
+
+// DomainEvent 聚合在状态变化时记录的领域事件
+// 应用服务在事务提交后通过 PullEvents 取出并分发，事务回滚时事件随聚合一起丢弃
+type DomainEvent interface {
+	EventName() string
+}
+
+// UserRegistered 用户注册成功
+type UserRegistered struct {
+	UserID uint64 `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (UserRegistered) EventName() string { return EventUserRegistered }
+
+// OrderCreated 订单已创建
+type OrderCreated struct {
+	OrderEvent
+}
+
+func (OrderCreated) EventName() string { return EventOrderCreated }
+
+// OrderInvalidated 订单已失效（取消）
+type OrderInvalidated struct {
+	OrderEvent
+}
+
+func (OrderInvalidated) EventName() string { return EventOrderInvalidated }
+
+// ConsumptionChanged 用户消费总额发生变化
+type ConsumptionChanged struct {
+	UserID uint64 `json:"user_id"`
+	Delta  Money  `json:"delta"` // 基准币种
+}
+
+func (ConsumptionChanged) EventName() string { return EventConsumptionChanged }
+
+// eventRecorder 聚合内的事件记录
+// 保存的是生成事件的函数：聚合ID在保存后才由数据库分配，取出事件时才读取
+type eventRecorder struct {
+	pending []func() DomainEvent
+}
+
+func (r *eventRecorder) record(event func() DomainEvent) {
+	r.pending = append(r.pending, event)
+}
+
+// PullEvents 按记录顺序取出事件并清空，只应在事务提交后调用
+func (r *eventRecorder) PullEvents() []DomainEvent {
+	events := make([]DomainEvent, 0, len(r.pending))
+	for _, event := range r.pending {
+		events = append(events, event())
+	}
+	r.pending = nil
+	return events
+}
+
+// orderEvent 订单当前状态对应的事件内容
+func (o *Order) orderEvent() OrderEvent {
+	return OrderEvent{
+		OrderID:    o.OrderID,
+		UserID:     o.UserID,
+		Amount:     o.Amount,
+		Currency:   o.Currency,
+		BaseAmount: o.BaseAmount,
+		Status:     o.Status,
+	}
+}
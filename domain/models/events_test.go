@@ -0,0 +1,45 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserEvents(t *testing.T) {
+	user, err := models.CreateUser("Alice", "alice@example.com", nil)
+	assert.NoError(t, err)
+	user.ID = 7 // 保存后由数据库分配
+
+	assert.NoError(t, user.AddConsumption(models.MustParseMoney("100")))
+	assert.NoError(t, user.AddConsumption(models.Money{})) // 没有变化时不记录
+	assert.Error(t, user.AddConsumption(models.MustParseMoney("-500")))
+
+	events := user.PullEvents()
+	assert.Equal(t, []models.DomainEvent{
+		models.UserRegistered{UserID: 7, Email: "alice@example.com"},
+		models.ConsumptionChanged{UserID: 7, Delta: models.MustParseMoney("100")},
+	}, events)
+	assert.Empty(t, user.PullEvents(), "取出后清空")
+}
+
+func TestOrderEvents(t *testing.T) {
+	user := &models.User{ID: 7}
+	order, err := user.CreateOrder(7, models.MustParseMoney("500"), models.BaseExchangeRate())
+	assert.NoError(t, err)
+	order.OrderID = 1001 // 保存后由数据库分配
+
+	_, err = order.Invalidate()
+	assert.NoError(t, err)
+
+	events := order.PullEvents()
+	if assert.Len(t, events, 2) {
+		created := events[0].(models.OrderCreated)
+		assert.Equal(t, uint64(1001), created.OrderID)
+		assert.Equal(t, models.OrderPending, created.Status, "记录时的状态")
+		assert.Equal(t, models.EventOrderInvalidated, events[1].EventName())
+		assert.Equal(t, models.OrderCancelled, events[1].(models.OrderInvalidated).Status)
+	}
+	assert.Empty(t, order.PullEvents())
+}
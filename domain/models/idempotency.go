@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// IdempotencyRecord 幂等键记录：同一个键只会产生一次业务结果
+type IdempotencyRecord struct {
+	Key         string    `gorm:"primaryKey;type:varchar(128)"`
+	RequestHash string    `gorm:"type:char(64);not null"` // 请求内容摘要，用于发现同键不同请求
+	ResourceID  uint64    `gorm:"not null"`               // 第一次请求产生的资源ID（如订单ID）
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 金额与数据库列 decimal(12,2) 对应：两位小数，绝对值不超过 9999999999.99
+const (
+	moneyScale    = 100
+	maxMoneyCents = 999999999999
+)
+
+// Money 金额值对象，内部以“分”为单位的整数保存，避免浮点误差
+//
+// 舍入规则：超过两位小数时按四舍五入（远离零）保留两位。
+// 溢出规则：运算结果的绝对值超过 decimal(12,2) 的范围时返回 ErrMoneyOverflow。
+type Money struct {
+	cents int64
+}
+
+// MoneyFromCents 以分为单位构造金额
+func MoneyFromCents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+// ParseMoney 解析十进制字符串，如 "12.34"、"-0.5"、"100"
+func ParseMoney(s string) (Money, error) {
+	cents, err := parseFixed(s, 2)
+	if err != nil {
+		return Money{}, err
+	}
+	return checkedMoney(cents)
+}
+
+// parseFixed 把十进制字符串解析为保留 places 位小数的定点整数，多余的小数按四舍五入（远离零）处理
+// 整数部分最多10位，与 decimal(12,2) / decimal(18,8) 的整数位数一致
+func parseFixed(s string, places int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrMoneyFormat
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrMoneyFormat
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, ErrMoneyFormat.With("value", s)
+			}
+		}
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(intPart) > 10 {
+		return 0, ErrMoneyOverflow
+	}
+	scale := int64(math.Pow10(places))
+	var value int64
+	if intPart != "" {
+		units, _ := strconv.ParseInt(intPart, 10, 64)
+		value = units * scale
+	}
+
+	// 保留 places 位小数，下一位四舍五入
+	fracPart += strings.Repeat("0", places+1)
+	if places > 0 {
+		frac, _ := strconv.ParseInt(fracPart[:places], 10, 64)
+		value += frac
+	}
+	if fracPart[places] >= '5' {
+		value++
+	}
+
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// MustParseMoney 同 ParseMoney，解析失败时 panic，仅用于常量与测试
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// MoneyFromFloat 把浮点数按最短十进制表示转换为金额（如 0.1 → 0.10）
+func MoneyFromFloat(f float64) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Money{}, ErrMoneyFormat
+	}
+	return ParseMoney(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func checkedMoney(cents int64) (Money, error) {
+	if cents > maxMoneyCents || cents < -maxMoneyCents {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{cents: cents}, nil
+}
+
+// Cents 以分为单位的整数值
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// Add 相加，结果超出范围时返回 ErrMoneyOverflow
+func (m Money) Add(o Money) (Money, error) {
+	return checkedMoney(m.cents + o.cents)
+}
+
+// Sub 相减，结果超出范围时返回 ErrMoneyOverflow
+func (m Money) Sub(o Money) (Money, error) {
+	return checkedMoney(m.cents - o.cents)
+}
+
+// Mul 乘以整数（如数量），结果超出范围时返回 ErrMoneyOverflow
+func (m Money) Mul(n int64) (Money, error) {
+	if n != 0 && (m.cents*n)/n != m.cents {
+		return Money{}, ErrMoneyOverflow
+	}
+	return checkedMoney(m.cents * n)
+}
+
+// Neg 取相反数
+func (m Money) Neg() Money {
+	return Money{cents: -m.cents}
+}
+
+// Cmp 比较大小：m < o 返回 -1，相等返回 0，m > o 返回 1
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.cents < o.cents:
+		return -1
+	case m.cents > o.cents:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool     { return m.cents == 0 }
+func (m Money) IsNegative() bool { return m.cents < 0 }
+
+// String 两位小数的十进制表示，如 "12.30"
+func (m Money) String() string {
+	sign := ""
+	cents := m.cents
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/moneyScale, cents%moneyScale)
+}
+
+// Value 实现 driver.Valuer，以十进制字符串写入 decimal 列，避免经过浮点数
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan 实现 sql.Scanner，MySQL 的 decimal 列以 []byte 返回
+func (m *Money) Scan(src interface{}) error {
+	var parsed Money
+	var err error
+	switch v := src.(type) {
+	case nil:
+	case []byte:
+		parsed, err = ParseMoney(string(v))
+	case string:
+		parsed, err = ParseMoney(v)
+	case int64:
+		parsed, err = ParseMoney(strconv.FormatInt(v, 10))
+	case float64:
+		parsed, err = MoneyFromFloat(v)
+	default:
+		return fmt.Errorf("无法将 %T 转换为金额", src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalJSON 输出为JSON数字，如 12.30
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 接受JSON数字或字符串
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	s := strings.Trim(string(data), `"`)
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
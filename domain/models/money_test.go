@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		in    string
+		cents int64
+	}{
+		{"100", 10000},
+		{"12.3", 1230},
+		{"12.34", 1234},
+		{"-0.5", -50},
+		{"+.5", 50},
+		{"0.125", 13}, // 第三位四舍五入
+		{"0.124", 12},
+		{"-0.125", -13}, // 远离零
+		{"9999999999.99", maxMoneyCents},
+	}
+	for _, c := range cases {
+		m, err := ParseMoney(c.in)
+		if err != nil {
+			t.Errorf("%q: %v", c.in, err)
+			continue
+		}
+		if m.Cents() != c.cents {
+			t.Errorf("%q: 期望 %d 分，实际 %d 分", c.in, c.cents, m.Cents())
+		}
+	}
+
+	for _, in := range []string{"", "-", ".", "1.2.3", "abc", "1e3"} {
+		if _, err := ParseMoney(in); !errors.Is(err, ErrMoneyFormat) {
+			t.Errorf("%q: 期望格式错误，实际 %v", in, err)
+		}
+	}
+	for _, in := range []string{"10000000000", "9999999999.999"} {
+		if _, err := ParseMoney(in); !errors.Is(err, ErrMoneyOverflow) {
+			t.Errorf("%q: 期望溢出错误，实际 %v", in, err)
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 浮点数下 0.1+0.2 != 0.3
+	sum, err := MustParseMoney("0.1").Add(MustParseMoney("0.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != MustParseMoney("0.3") {
+		t.Errorf("期望 0.30，实际 %s", sum)
+	}
+
+	f, err := MoneyFromFloat(0.1 + 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != MustParseMoney("0.3") {
+		t.Errorf("期望 0.30，实际 %s", f)
+	}
+
+	max := MoneyFromCents(maxMoneyCents)
+	if _, err := max.Add(MoneyFromCents(1)); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("期望溢出错误，实际 %v", err)
+	}
+	if _, err := max.Neg().Sub(MoneyFromCents(1)); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("期望溢出错误，实际 %v", err)
+	}
+
+	if got := MustParseMoney("-3.05").String(); got != "-3.05" {
+		t.Errorf("期望 -3.05，实际 %s", got)
+	}
+	if MustParseMoney("1").Cmp(MustParseMoney("2")) != -1 {
+		t.Error("比较结果异常")
+	}
+}
+
+func TestMoney_ScanValue(t *testing.T) {
+	v, err := MustParseMoney("12.3").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "12.30" {
+		t.Errorf("期望 12.30，实际 %v", v)
+	}
+
+	for _, src := range []interface{}{[]byte("12.30"), "12.3", 12.3} {
+		var m Money
+		if err := m.Scan(src); err != nil {
+			t.Fatalf("%v: %v", src, err)
+		}
+		if m != MustParseMoney("12.3") {
+			t.Errorf("%v: 实际 %s", src, m)
+		}
+	}
+
+	var m Money
+	if err := m.Scan(int64(7)); err != nil || m != MustParseMoney("7") {
+		t.Errorf("int64 扫描异常: %s %v", m, err)
+	}
+	if err := m.Scan(true); err == nil {
+		t.Error("不支持的类型应返回错误")
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(OrderEvent{OrderID: 1, UserID: 2, Amount: MustParseMoney("0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"order_id":1,"user_id":2,"amount":0.10,"currency":"","base_amount":0.00,"status":""}` {
+		t.Errorf("序列化结果异常: %s", data)
+	}
+
+	var event OrderEvent
+	if err := json.Unmarshal([]byte(`{"amount":"99.99"}`), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Amount != MustParseMoney("99.99") {
+		t.Errorf("期望 99.99，实际 %s", event.Amount)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":12.345}`), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Amount != MustParseMoney("12.35") {
+		t.Errorf("期望 12.35，实际 %s", event.Amount)
+	}
+}
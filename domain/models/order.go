@@ -1,24 +1,129 @@
 package models
 
 import (
-	"errors"
 	"time"
 )
 
+// OrderStatus 订单状态
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"   // 已下单，待支付
+	OrderPaid      OrderStatus = "paid"      // 已支付
+	OrderShipped   OrderStatus = "shipped"   // 已发货
+	OrderCompleted OrderStatus = "completed" // 已完成
+	OrderCancelled OrderStatus = "cancelled" // 已取消（终态）
+	OrderRefunded  OrderStatus = "refunded"  // 已退款（终态）
+)
+
+// orderTransitions 每个状态允许变更到的下一个状态，终态没有后继
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderCancelled, OrderRefunded},
+	OrderShipped:   {OrderCompleted, OrderRefunded},
+	OrderCompleted: {OrderRefunded},
+}
+
+// IsKnown 是否为已定义的状态
+func (s OrderStatus) IsKnown() bool {
+	switch s {
+	case OrderPending, OrderPaid, OrderShipped, OrderCompleted, OrderCancelled, OrderRefunded:
+		return true
+	}
+	return false
+}
+
+// CountsTowardConsumption 处于该状态的订单金额是否计入用户消费总额
+// 订单创建即计入（与引入状态之前的行为一致），取消或退款后扣除
+func (s OrderStatus) CountsTowardConsumption() bool {
+	switch s {
+	case OrderPending, OrderPaid, OrderShipped, OrderCompleted:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo 是否允许从 s 变更为 next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Order struct {
 	// gorm.Model
-	OrderID   uint64    `gorm:"primaryKey;autoIncrement;column:order_id;comment:订单ID"`
-	UserID    uint64    `gorm:"column:user_id;index:idx_user_id;comment:关联用户ID"`
-	Amount    float64   `gorm:"column:amount;type:decimal(12,2);not null;comment:订单金额"`
-	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime;comment:创建时间"`
-	IsValid   bool      `gorm:"column:is_valid;type:tinyint(1);default:1;comment:有效性标识(0:无效 1:有效)"`
+	OrderID  uint64   `gorm:"primaryKey;autoIncrement;column:order_id;comment:订单ID"`
+	UserID   uint64   `gorm:"column:user_id;index:idx_user_id;index:idx_order_user_created,priority:1;comment:关联用户ID"`
+	Amount   Money    `gorm:"column:amount;type:decimal(12,2);not null;comment:订单金额"`
+	Currency Currency `gorm:"column:currency;type:char(3);not null;default:CNY;comment:订单币种"`
+	// 下单时使用的汇率及折算为基准币种后的金额，用于审计；消费总额按 BaseAmount 计算
+	ExchangeRate Rate  `gorm:"column:exchange_rate;type:decimal(18,8);not null;default:1;comment:下单汇率"`
+	BaseAmount   Money `gorm:"column:base_amount;type:decimal(12,2);not null;default:0;comment:基准币种金额"`
+	// 累计退款金额，不超过订单金额；已退款部分不再计入消费总额
+	RefundedAmount     Money       `gorm:"column:refunded_amount;type:decimal(12,2);not null;default:0;comment:累计退款金额"`
+	RefundedBaseAmount Money       `gorm:"column:refunded_base_amount;type:decimal(12,2);not null;default:0;comment:累计退款金额（基准币种）"`
+	CreatedAt          time.Time   `gorm:"column:created_at;autoCreateTime;index:idx_order_user_created,priority:2;comment:创建时间"`
+	Status             OrderStatus `gorm:"column:status;type:varchar(20);not null;default:pending;index:idx_status;comment:订单状态"`
+	Version            uint64      `gorm:"column:version;not null;default:0;comment:乐观锁版本号"`
+	// 订单明细，按金额直接下单的订单没有明细
+	Items []OrderItem `gorm:"foreignKey:OrderID;references:OrderID"`
+
+	eventRecorder `gorm:"-"` // 领域事件，不持久化
+}
+
+// IsActive 订单金额是否计入消费总额（未取消、未退款）
+func (o *Order) IsActive() bool {
+	return o.Status.CountsTowardConsumption()
+}
+
+// countedAmount 计入消费总额的基准币种金额（扣除已退款部分）
+func (o *Order) countedAmount() Money {
+	counted, _ := o.BaseAmount.Sub(o.RefundedBaseAmount)
+	return counted
+}
+
+// ReleasesStock 订单处于该状态时是否应归还明细占用的库存
+func (s OrderStatus) ReleasesStock() bool {
+	return s == OrderCancelled
 }
 
-// Invalidate: 订单失效（触发消费总额调整）
-func (o *Order) Invalidate() (float64, error) {
-	if o.IsValid == false {
-		return 0, errors.New("订单已失效")
+// TransitionTo 变更订单状态，返回本次变更对用户消费总额（基准币种）的影响
+// 退款需要记录退款金额，只能通过 Refund 变更为 refunded
+func (o *Order) TransitionTo(next OrderStatus) (Money, error) {
+	if next == OrderRefunded {
+		// 退款需要记录退款金额，只能通过 Refund
+		return Money{}, ErrInvalidTransition.With("from", o.Status).With("to", next)
+	}
+	if !o.Status.CanTransitionTo(next) {
+		return Money{}, ErrInvalidTransition.With("from", o.Status).With("to", next)
+	}
+	var delta Money
+	switch was, now := o.Status.CountsTowardConsumption(), next.CountsTowardConsumption(); {
+	case was && !now:
+		delta = o.countedAmount().Neg()
+	case !was && now:
+		delta = o.countedAmount()
+	}
+	o.Status = next
+	return delta, nil
+}
+
+// Invalidate: 订单失效即取消订单（触发消费总额调整），返回需要从消费总额中扣除的基准币种金额
+// 只有 pending / paid 的订单可以取消；已发货、已完成的订单返回 ErrInvalidTransition，需要走退款（Refund）
+func (o *Order) Invalidate() (Money, error) {
+	if !o.IsActive() {
+		return Money{}, ErrOrderInactive.With("order_id", o.OrderID).With("status", o.Status)
+	}
+	delta, err := o.TransitionTo(OrderCancelled)
+	if err != nil {
+		return Money{}, err
 	}
-	o.IsValid = false
-	return o.Amount, nil
+	event := o.orderEvent()
+	o.record(func() DomainEvent {
+		return OrderInvalidated{event}
+	})
+	return delta.Neg(), nil
 }
@@ -1,11 +1,12 @@
 package models
 
 import (
+	"errors"
 	"testing"
 )
 
 func TestOrder_Invalidate(t *testing.T) {
-	order := &Order{Amount: 200, IsValid: true}
+	order := &Order{Amount: MustParseMoney("200"), BaseAmount: MustParseMoney("200"), Status: OrderPending}
 
 	// 有效订单失效测试
 	t.Run("ValidOrder", func(t *testing.T) {
@@ -13,7 +14,7 @@ func TestOrder_Invalidate(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if amount != 200 || order.IsValid {
+		if amount != MustParseMoney("200") || order.Status != OrderCancelled {
 			t.Error("失效逻辑异常")
 		}
 	})
@@ -25,3 +26,158 @@ func TestOrder_Invalidate(t *testing.T) {
 		}
 	})
 }
+
+// 已发货、已完成的订单不能直接失效，需要退款
+func TestOrder_Invalidate_AfterShipment(t *testing.T) {
+	for _, status := range []OrderStatus{OrderShipped, OrderCompleted} {
+		order := &Order{Amount: MustParseMoney("200"), BaseAmount: MustParseMoney("200"), Status: status}
+		if _, err := order.Invalidate(); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%s: 期望状态变更错误，实际 %v", status, err)
+		}
+		if order.Status != status {
+			t.Errorf("%s: 失败时不应修改状态，实际 %s", status, order.Status)
+		}
+		if events := order.PullEvents(); len(events) != 0 {
+			t.Errorf("%s: 失败时不应记录事件", status)
+		}
+	}
+}
+
+func TestOrder_TransitionTo(t *testing.T) {
+	amount := MustParseMoney("300")
+
+	// 正常流转：只有离开计入消费总额的状态时才扣除
+	t.Run("Lifecycle", func(t *testing.T) {
+		order := &Order{BaseAmount: amount, Status: OrderPending}
+		steps := []struct {
+			next  OrderStatus
+			delta Money
+		}{
+			{OrderPaid, Money{}},
+			{OrderShipped, Money{}},
+			{OrderCompleted, Money{}},
+		}
+		for _, step := range steps {
+			delta, err := order.TransitionTo(step.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if delta != step.delta || order.Status != step.next {
+				t.Errorf("%s: 期望 %s，实际 %s", step.next, step.delta, delta)
+			}
+		}
+	})
+
+	// 非法流转
+	t.Run("InvalidTransition", func(t *testing.T) {
+		cases := []struct{ from, to OrderStatus }{
+			{OrderPending, OrderShipped},
+			{OrderShipped, OrderCancelled},
+			{OrderCompleted, OrderPaid},
+			{OrderCancelled, OrderPaid},
+			{OrderRefunded, OrderRefunded},
+			{OrderPaid, OrderRefunded}, // 退款只能通过 Refund
+		}
+		for _, c := range cases {
+			order := &Order{BaseAmount: amount, Status: c.from}
+			if _, err := order.TransitionTo(c.to); !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("%s -> %s: 期望状态错误，实际 %v", c.from, c.to, err)
+			}
+			if order.Status != c.from {
+				t.Errorf("%s -> %s: 失败时不应修改状态", c.from, c.to)
+			}
+		}
+	})
+
+	// 已发货订单不能直接失效
+	t.Run("InvalidateShipped", func(t *testing.T) {
+		order := &Order{BaseAmount: amount, Status: OrderShipped}
+		if _, err := order.Invalidate(); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("期望状态错误，实际 %v", err)
+		}
+	})
+}
+
+func TestOrder_Refund(t *testing.T) {
+	// 100 USD，汇率 7.1234，折合 712.34 CNY
+	newOrder := func() *Order {
+		return &Order{OrderID: 1, Amount: MustParseMoney("100"), Currency: USD, ExchangeRate: MustParseRate("7.1234"),
+			BaseAmount: MustParseMoney("712.34"), Status: OrderPaid}
+	}
+
+	// 多次部分退款后全额退完，基准币种合计等于订单的基准币种金额
+	t.Run("PartialThenFull", func(t *testing.T) {
+		order := newOrder()
+		first, err := order.Refund(MustParseMoney("33.33"), "部分商品缺货")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.BaseAmount != MustParseMoney("237.42") || order.Status != OrderPaid {
+			t.Errorf("部分退款异常: %s %s", first.BaseAmount, order.Status)
+		}
+		if order.RefundableAmount() != MustParseMoney("66.67") {
+			t.Errorf("剩余可退金额异常: %s", order.RefundableAmount())
+		}
+
+		second, err := order.Refund(MustParseMoney("66.67"), "取消剩余商品")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total, _ := first.BaseAmount.Add(second.BaseAmount); total != order.BaseAmount {
+			t.Errorf("退款合计 %s 与订单金额 %s 不一致", total, order.BaseAmount)
+		}
+		if order.Status != OrderRefunded || !order.RefundableAmount().IsZero() {
+			t.Errorf("全额退款后状态异常: %s", order.Status)
+		}
+	})
+
+	// 超额退款
+	t.Run("ExceedsPaid", func(t *testing.T) {
+		order := newOrder()
+		if _, err := order.Refund(MustParseMoney("60"), "退货"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := order.Refund(MustParseMoney("40.01"), "退货"); !errors.Is(err, ErrRefundExceedsPaid) {
+			t.Errorf("期望超额退款错误，实际 %v", err)
+		}
+		if order.RefundedAmount != MustParseMoney("60") {
+			t.Errorf("失败时不应修改退款金额: %s", order.RefundedAmount)
+		}
+	})
+
+	// 参数与状态校验
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := newOrder().Refund(Money{}, "退货"); err == nil {
+			t.Error("退款金额为0时应返回错误")
+		}
+		if _, err := newOrder().Refund(MustParseMoney("1"), " "); err == nil {
+			t.Error("退款原因为空时应返回错误")
+		}
+		yen := newOrder()
+		yen.Currency = JPY
+		if _, err := yen.Refund(MustParseMoney("1.5"), "退货"); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("日元退款金额不能有小数，实际 %v", err)
+		}
+		pending := newOrder()
+		pending.Status = OrderPending
+		if _, err := pending.Refund(MustParseMoney("1"), "退货"); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("未支付订单不能退款，实际 %v", err)
+		}
+	})
+
+	// 部分退款后取消只扣除剩余金额
+	t.Run("CancelAfterPartialRefund", func(t *testing.T) {
+		order := newOrder()
+		refund, err := order.Refund(MustParseMoney("50"), "退货")
+		if err != nil {
+			t.Fatal(err)
+		}
+		delta, err := order.TransitionTo(OrderCancelled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total, _ := refund.BaseAmount.Add(delta.Neg()); total != order.BaseAmount {
+			t.Errorf("取消时扣除金额异常: %s", delta)
+		}
+	})
+}
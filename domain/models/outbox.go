@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// 聚合类型
+const (
+	AggregateUser  = "user"
+	AggregateOrder = "order"
+)
+
+// 事件类型
+const (
+	EventOrderCreated       = "OrderCreated"
+	EventOrderInvalidated   = "OrderInvalidated"
+	EventOrderStatusChanged = "OrderStatusChanged"
+	EventOrderRefunded      = "OrderRefunded"
+	EventConsumptionChanged = "ConsumptionChanged"
+)
+
+// OrderEvent 订单事件（OrderCreated / OrderInvalidated）的内容
+type OrderEvent struct {
+	OrderID    uint64      `json:"order_id"`
+	UserID     uint64      `json:"user_id"`
+	Amount     Money       `json:"amount"`      // 订单币种金额
+	Currency   Currency    `json:"currency"`    // 订单币种
+	BaseAmount Money       `json:"base_amount"` // 基准币种金额
+	Status     OrderStatus `json:"status"`      // 事件发生后的订单状态
+}
+
+// ConsumptionChangedEvent 用户消费总额变化事件的内容
+type ConsumptionChangedEvent struct {
+	UserID  uint64 `json:"user_id"`
+	Delta   Money  `json:"delta"`    // 基准币种
+	OrderID uint64 `json:"order_id"` // 引起变化的订单
+}
+
+// OutboxMessage 事务性发件箱中的一条待发布事件
+// 与业务数据在同一事务中写入，由中继任务异步发布（至少一次）
+// 发布失败后在 NextAttemptAt 之后重试，失败次数达到上限后停放（DeadAt 不为空），不再发布
+type OutboxMessage struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement"`
+	AggregateType string     `gorm:"type:varchar(50);not null;index:idx_outbox_aggregate,priority:1"`
+	AggregateID   uint64     `gorm:"not null;index:idx_outbox_aggregate,priority:2"`
+	EventType     string     `gorm:"type:varchar(100);not null"`
+	Payload       string     `gorm:"type:text;not null"` // JSON
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+	PublishedAt   *time.Time `gorm:"index:idx_outbox_published_at"` // 为空表示待发布
+	Attempts      int        `gorm:"not null;default:0"`            // 发布失败次数
+	LastError     string     `gorm:"type:varchar(500)"`
+	NextAttemptAt *time.Time `gorm:"index:idx_outbox_next_attempt_at"` // 为空表示立即发布
+	DeadAt        *time.Time `gorm:"index:idx_outbox_dead_at"`         // 停放时间，停放的消息需要人工处理
+}
+
+// NewOutboxMessage: 创建待发布事件，payload 序列化为JSON
+func NewOutboxMessage(aggregateType string, aggregateID uint64, eventType string, payload interface{}) (*OutboxMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(data),
+	}, nil
+}
+
+// AggregateKey 同一聚合的事件需要按顺序发布
+func (m *OutboxMessage) AggregateKey() string {
+	return m.AggregateType + ":" + strconv.FormatUint(m.AggregateID, 10)
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// erasedEmailDomain 抹除后的邮箱使用保留的 .invalid 域名（RFC 2606），不会被投递
+const erasedEmailDomain = "erased.invalid"
+
+// Anonymize 抹除用户的个人信息：用户名与邮箱替换为随机令牌（不可逆），同时注销用户
+// 订单等财务数据不受影响，消费总额照常保留以便对账
+func (u *User) Anonymize(now time.Time) error {
+	if u.ErasedAt != nil {
+		return ErrUserErased
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := "erased-" + hex.EncodeToString(buf)
+
+	u.Name = token
+	u.Email = token + "@" + erasedEmailDomain
+	u.CanonicalEmail = u.Email
+	if u.Status != UserDeleted {
+		u.Status = UserDeleted
+		u.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	}
+	u.ErasedAt = &now
+	return nil
+}
+
+// UserErasedDetail 抹除操作的审计内容，只记录数量不记录个人信息
+type UserErasedDetail struct {
+	Orders              int `json:"orders"`                // 保留的订单数
+	EmailChangesRemoved int `json:"email_changes_removed"` // 删除的邮箱变更请求数
+}
+
+// UserExport 用户数据导出的归档内容
+type UserExport struct {
+	ExportedAt   time.Time          `json:"exported_at"`
+	Profile      UserProfileExport  `json:"profile"`
+	Orders       []OrderExport      `json:"orders"`
+	AuditEntries []AuditEntryExport `json:"audit_entries"`
+}
+
+// UserProfileExport 用户资料
+type UserProfileExport struct {
+	ID               uint64     `json:"id"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	Status           UserStatus `json:"status"`
+	TotalConsumption Money      `json:"total_consumption"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	ErasedAt         *time.Time `json:"erased_at,omitempty"`
+}
+
+// OrderExport 订单及其明细与退款记录
+type OrderExport struct {
+	OrderID            uint64            `json:"order_id"`
+	Amount             Money             `json:"amount"`
+	Currency           Currency          `json:"currency"`
+	ExchangeRate       Rate              `json:"exchange_rate"`
+	BaseAmount         Money             `json:"base_amount"`
+	RefundedAmount     Money             `json:"refunded_amount"`
+	RefundedBaseAmount Money             `json:"refunded_base_amount"`
+	Status             OrderStatus       `json:"status"`
+	CreatedAt          time.Time         `json:"created_at"`
+	Items              []OrderItemExport `json:"items"`
+	Refunds            []RefundExport    `json:"refunds"`
+}
+
+// OrderItemExport 订单明细
+type OrderItemExport struct {
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	UnitPrice Money    `json:"unit_price"`
+	Currency  Currency `json:"currency"`
+	Quantity  int      `json:"quantity"`
+	Subtotal  Money    `json:"subtotal"`
+}
+
+// RefundExport 退款记录
+type RefundExport struct {
+	ID         uint64    `json:"id"`
+	Amount     Money     `json:"amount"`
+	BaseAmount Money     `json:"base_amount"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditEntryExport 与用户相关的审计记录
+type AuditEntryExport struct {
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Detail    json.RawMessage `json:"detail"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewUserExport: 汇总用户的资料、订单（含明细）、退款与审计记录，refunds 以订单ID为键
+func NewUserExport(user *User, orders []*Order, refunds map[uint64][]*Refund, audits []*AuditEntry, now time.Time) *UserExport {
+	export := &UserExport{
+		ExportedAt: now,
+		Profile: UserProfileExport{
+			ID:               user.ID,
+			Name:             user.Name,
+			Email:            user.Email,
+			Status:           user.Status,
+			TotalConsumption: user.TotalConsumption,
+			ErasedAt:         user.ErasedAt,
+		},
+		Orders:       make([]OrderExport, 0, len(orders)),
+		AuditEntries: make([]AuditEntryExport, 0, len(audits)),
+	}
+	if user.DeletedAt.Valid {
+		export.Profile.DeletedAt = &user.DeletedAt.Time
+	}
+
+	for _, order := range orders {
+		o := OrderExport{
+			OrderID:            order.OrderID,
+			Amount:             order.Amount,
+			Currency:           order.Currency,
+			ExchangeRate:       order.ExchangeRate,
+			BaseAmount:         order.BaseAmount,
+			RefundedAmount:     order.RefundedAmount,
+			RefundedBaseAmount: order.RefundedBaseAmount,
+			Status:             order.Status,
+			CreatedAt:          order.CreatedAt,
+			Items:              make([]OrderItemExport, 0, len(order.Items)),
+			Refunds:            make([]RefundExport, 0, len(refunds[order.OrderID])),
+		}
+		for _, item := range order.Items {
+			o.Items = append(o.Items, OrderItemExport{
+				SKU:       item.SKU,
+				Name:      item.Name,
+				UnitPrice: item.UnitPrice,
+				Currency:  item.Currency,
+				Quantity:  item.Quantity,
+				Subtotal:  item.Subtotal,
+			})
+		}
+		for _, refund := range refunds[order.OrderID] {
+			o.Refunds = append(o.Refunds, RefundExport{
+				ID:         refund.ID,
+				Amount:     refund.Amount,
+				BaseAmount: refund.BaseAmount,
+				Reason:     refund.Reason,
+				CreatedAt:  refund.CreatedAt,
+			})
+		}
+		export.Orders = append(export.Orders, o)
+	}
+
+	for _, entry := range audits {
+		export.AuditEntries = append(export.AuditEntries, AuditEntryExport{
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			Detail:    json.RawMessage(entry.Detail),
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return export
+}
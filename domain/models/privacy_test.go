@@ -0,0 +1,75 @@
+package models_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_Anonymize(t *testing.T) {
+	now := time.Now()
+	user := &models.User{ID: 1001, Name: "Alice", Email: "Alice@corp.com", CanonicalEmail: "alice@corp.com",
+		TotalConsumption: models.MustParseMoney("100"), Status: models.UserActive}
+
+	assert.NoError(t, user.Anonymize(now))
+	assert.NotContains(t, user.Name, "Alice")
+	assert.True(t, strings.HasPrefix(user.Name, "erased-"))
+	assert.True(t, strings.HasSuffix(user.Email, "@erased.invalid"))
+	assert.Equal(t, user.Email, user.CanonicalEmail)
+	assert.Equal(t, models.UserDeleted, user.Status)
+	assert.True(t, user.DeletedAt.Valid)
+	assert.Equal(t, &now, user.ErasedAt)
+	// 消费总额保留以便对账
+	assert.Equal(t, models.MustParseMoney("100"), user.TotalConsumption)
+
+	assert.ErrorIs(t, user.Anonymize(now), models.ErrUserErased)
+
+	t.Run("每个用户的令牌不同", func(t *testing.T) {
+		other := &models.User{ID: 1002}
+		assert.NoError(t, other.Anonymize(now))
+		assert.NotEqual(t, user.CanonicalEmail, other.CanonicalEmail)
+	})
+
+	t.Run("已注销的用户保留原注销时间", func(t *testing.T) {
+		deleted := &models.User{ID: 1003}
+		assert.NoError(t, deleted.Delete(now.Add(-time.Hour)))
+		assert.NoError(t, deleted.Anonymize(now))
+		assert.Equal(t, now.Add(-time.Hour), deleted.DeletedAt.Time)
+	})
+}
+
+func TestNewUserExport(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: 1001, Name: "Alice", Email: "alice@corp.com", Status: models.UserActive,
+		TotalConsumption: models.MustParseMoney("100")}
+	orders := []*models.Order{{
+		OrderID: 1, UserID: 1001, Amount: models.MustParseMoney("100"), Currency: models.CNY, ExchangeRate: models.RateOne,
+		BaseAmount: models.MustParseMoney("100"), Status: models.OrderPaid,
+		Items: []models.OrderItem{{SKU: "SKU-001", Quantity: 1, UnitPrice: models.MustParseMoney("100"), Subtotal: models.MustParseMoney("100")}},
+	}}
+	refunds := map[uint64][]*models.Refund{1: {{ID: 7, OrderID: 1, Amount: models.MustParseMoney("10"), Reason: "退货"}}}
+	audit, err := models.NewAuditEntry("admin", models.AuditUserErased, models.AggregateUser, 1001, models.UserErasedDetail{Orders: 1})
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(models.NewUserExport(user, orders, refunds, []*models.AuditEntry{audit}, now))
+	assert.NoError(t, err)
+
+	var archive map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &archive))
+	assert.Equal(t, "alice@corp.com", archive["profile"].(map[string]interface{})["email"])
+	exportedOrders := archive["orders"].([]interface{})
+	if assert.Len(t, exportedOrders, 1) {
+		order := exportedOrders[0].(map[string]interface{})
+		assert.Len(t, order["items"], 1)
+		assert.Len(t, order["refunds"], 1)
+	}
+	audits := archive["audit_entries"].([]interface{})
+	if assert.Len(t, audits, 1) {
+		assert.Equal(t, map[string]interface{}{"orders": float64(1), "email_changes_removed": float64(0)},
+			audits[0].(map[string]interface{})["detail"])
+	}
+}
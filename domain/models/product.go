@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxProductNameLength 商品名称的最大长度（字符数），与 products.name 列一致
+const maxProductNameLength = 200
+
+// OutOfStockError 商品库存不足以满足下单数量
+type OutOfStockError struct {
+	SKU       string
+	Requested int // 申请数量
+	Available int // 当前库存
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("商品%s库存不足: 需要%d，剩余%d", e.SKU, e.Requested, e.Available)
+}
+
+// Is 使 errors.Is(err, ErrOutOfStock) 成立
+func (e *OutOfStockError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == CodeOutOfStock
+}
+
+// As 使 errors.As 可以取出对应的 *DomainError（错误码 OUT_OF_STOCK）
+func (e *OutOfStockError) As(target any) bool {
+	t, ok := target.(**DomainError)
+	if ok {
+		*t = ErrOutOfStock.With("sku", e.SKU).With("requested", e.Requested).With("available", e.Available)
+	}
+	return ok
+}
+
+// Product 商品目录中的商品
+type Product struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	SKU       string    `gorm:"column:sku;type:varchar(64);not null;uniqueIndex"`
+	Name      string    `gorm:"type:varchar(200);not null"`
+	UnitPrice Money     `gorm:"type:decimal(12,2);not null;comment:单价"`
+	Currency  Currency  `gorm:"type:char(3);not null;default:CNY;comment:单价币种"`
+	Stock     int       `gorm:"not null;default:0;index:idx_product_stock;comment:库存数量"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// NewProduct: 创建商品
+func NewProduct(sku string, name string, unitPrice Money, currency Currency) (*Product, error) {
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return nil, ErrInvalidProduct.With("field", "sku").With("reason", "empty")
+	}
+	// 与用户名相同：去掉首尾空白并转为 NFC 形式后按字符数校验
+	name = norm.NFC.String(strings.TrimSpace(name))
+	if name == "" {
+		return nil, ErrInvalidProduct.With("field", "name").With("reason", "empty")
+	}
+	if utf8.RuneCountInString(name) > maxProductNameLength {
+		return nil, ErrInvalidProduct.With("field", "name").With("reason", "too_long").With("max_length", maxProductNameLength)
+	}
+	if unitPrice.IsNegative() {
+		return nil, ErrInvalidProduct.With("field", "unit_price")
+	}
+	currency, err := ParseCurrency(string(currency))
+	if err != nil {
+		return nil, err
+	}
+	if err := currency.CheckAmount(unitPrice); err != nil {
+		return nil, err
+	}
+	return &Product{SKU: sku, Name: name, UnitPrice: unitPrice, Currency: currency}, nil
+}
+
+// CheckStock 检查库存是否足够下单（仅做领域校验，实际扣减在数据库中按条件原子执行）
+func (p *Product) CheckStock(quantity int) error {
+	if p.Stock < quantity {
+		return &OutOfStockError{SKU: p.SKU, Requested: quantity, Available: p.Stock}
+	}
+	return nil
+}
+
+// Restock 补货
+func (p *Product) Restock(quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity.With("quantity", quantity)
+	}
+	p.Stock += quantity
+	return nil
+}
+
+// OrderItem 订单明细，商品信息与单价为下单时的快照，商品后续调价不影响已有订单
+type OrderItem struct {
+	ID        uint64   `gorm:"primaryKey;autoIncrement"`
+	OrderID   uint64   `gorm:"not null;index:idx_item_order_id"`
+	ProductID uint64   `gorm:"not null"`
+	SKU       string   `gorm:"column:sku;type:varchar(64);not null"`
+	Name      string   `gorm:"type:varchar(200);not null"`
+	UnitPrice Money    `gorm:"type:decimal(12,2);not null;comment:下单时的单价"`
+	Currency  Currency `gorm:"type:char(3);not null;default:CNY"`
+	Quantity  int      `gorm:"not null"`
+	Subtotal  Money    `gorm:"type:decimal(12,2);not null;comment:单价*数量"`
+}
+
+// NewOrderItem: 按商品当前价格生成订单明细
+func NewOrderItem(product *Product, quantity int) (OrderItem, error) {
+	if quantity <= 0 {
+		return OrderItem{}, ErrInvalidQuantity.With("sku", product.SKU).With("quantity", quantity)
+	}
+	subtotal, err := product.UnitPrice.Mul(int64(quantity))
+	if err != nil {
+		return OrderItem{}, err
+	}
+	return OrderItem{
+		ProductID: product.ID,
+		SKU:       product.SKU,
+		Name:      product.Name,
+		UnitPrice: product.UnitPrice,
+		Currency:  product.Currency,
+		Quantity:  quantity,
+		Subtotal:  subtotal,
+	}, nil
+}
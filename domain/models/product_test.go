@@ -0,0 +1,129 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProduct(t *testing.T) {
+	t.Run("日元单价不能有小数", func(t *testing.T) {
+		_, err := models.NewProduct("SKU-JP", "茶碗", models.MustParseMoney("1200.5"), models.JPY)
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
+
+		product, err := models.NewProduct("SKU-JP", "茶碗", models.MustParseMoney("1200"), models.JPY)
+		assert.NoError(t, err)
+		assert.Equal(t, models.JPY, product.Currency)
+	})
+}
+
+func TestNewProduct_Name(t *testing.T) {
+	t.Run("去掉首尾空白", func(t *testing.T) {
+		product, err := models.NewProduct(" SKU-002 ", "  保温杯 ", models.MustParseMoney("59"), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "SKU-002", product.SKU)
+		assert.Equal(t, "保温杯", product.Name)
+	})
+
+	t.Run("名称不能为空", func(t *testing.T) {
+		_, err := models.NewProduct("SKU-002", "   ", models.MustParseMoney("59"), "")
+		assert.ErrorIs(t, err, models.ErrInvalidProduct)
+		domainErr, _ := models.AsDomainError(err)
+		assert.Equal(t, "name", domainErr.Details["field"])
+	})
+
+	t.Run("名称按字符数限制长度", func(t *testing.T) {
+		_, err := models.NewProduct("SKU-002", strings.Repeat("杯", 200), models.MustParseMoney("59"), "")
+		assert.NoError(t, err)
+
+		_, err = models.NewProduct("SKU-002", strings.Repeat("杯", 201), models.MustParseMoney("59"), "")
+		assert.ErrorIs(t, err, models.ErrInvalidProduct)
+		assert.Equal(t, models.CategoryValidation, models.CategoryOf(err))
+	})
+}
+
+func TestNewOrderItem(t *testing.T) {
+	product, err := models.NewProduct("SKU-001", "水杯", models.MustParseMoney("19.9"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, models.BaseCurrency, product.Currency)
+
+	t.Run("按单价与数量计算小计", func(t *testing.T) {
+		item, err := models.NewOrderItem(product, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("59.7"), item.Subtotal)
+		assert.Equal(t, "SKU-001", item.SKU)
+	})
+
+	t.Run("下单后商品调价不影响明细快照", func(t *testing.T) {
+		item, err := models.NewOrderItem(product, 1)
+		assert.NoError(t, err)
+		product.UnitPrice = models.MustParseMoney("29.9")
+		assert.Equal(t, models.MustParseMoney("19.9"), item.UnitPrice)
+	})
+
+	t.Run("数量必须大于0", func(t *testing.T) {
+		_, err := models.NewOrderItem(product, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("小计溢出", func(t *testing.T) {
+		expensive := &models.Product{SKU: "SKU-999", UnitPrice: models.MustParseMoney("9999999999")}
+		_, err := models.NewOrderItem(expensive, 2)
+		assert.ErrorIs(t, err, models.ErrMoneyOverflow)
+	})
+}
+
+func TestUser_CreateOrderFromItems(t *testing.T) {
+	user := &models.User{ID: 1001}
+	cup := &models.Product{ID: 1, SKU: "SKU-001", UnitPrice: models.MustParseMoney("19.9"), Currency: models.CNY}
+	pen := &models.Product{ID: 2, SKU: "SKU-002", UnitPrice: models.MustParseMoney("35"), Currency: models.CNY}
+
+	t.Run("订单金额为明细小计之和", func(t *testing.T) {
+		first, _ := models.NewOrderItem(cup, 3)
+		second, _ := models.NewOrderItem(pen, 2)
+		order, err := user.CreateOrderFromItems(user.ID, []models.OrderItem{first, second}, models.BaseExchangeRate())
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("129.7"), order.Amount)
+		assert.Equal(t, models.MustParseMoney("129.7"), order.BaseAmount)
+		assert.Len(t, order.Items, 2)
+	})
+
+	t.Run("明细为空", func(t *testing.T) {
+		_, err := user.CreateOrderFromItems(user.ID, nil, models.BaseExchangeRate())
+		assert.Error(t, err)
+	})
+
+	t.Run("明细币种与订单币种不一致", func(t *testing.T) {
+		item, _ := models.NewOrderItem(cup, 1)
+		usd := &models.ExchangeRate{Currency: models.USD, Rate: models.MustParseRate("7.1")}
+		_, err := user.CreateOrderFromItems(user.ID, []models.OrderItem{item}, usd)
+		assert.ErrorContains(t, err, "币种")
+	})
+}
+
+func TestProduct_Stock(t *testing.T) {
+	product := &models.Product{SKU: "SKU-001", Stock: 2}
+
+	t.Run("库存足够", func(t *testing.T) {
+		assert.NoError(t, product.CheckStock(2))
+	})
+
+	t.Run("库存不足返回具体数量", func(t *testing.T) {
+		err := product.CheckStock(3)
+		assert.ErrorIs(t, err, models.ErrOutOfStock)
+		var outOfStock *models.OutOfStockError
+		if assert.ErrorAs(t, err, &outOfStock) {
+			assert.Equal(t, 3, outOfStock.Requested)
+			assert.Equal(t, 2, outOfStock.Available)
+		}
+	})
+
+	t.Run("补货", func(t *testing.T) {
+		assert.NoError(t, product.Restock(5))
+		assert.Equal(t, 7, product.Stock)
+		assert.Error(t, product.Restock(0))
+		assert.Equal(t, 7, product.Stock)
+	})
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxRefundReasonLength 退款原因的最大长度（字符数），与 refunds.reason 列一致
+const maxRefundReasonLength = 255
+
+// Refund 订单的一次退款（部分或全额），每次退款单独记录
+type Refund struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement"`
+	OrderID    uint64    `gorm:"not null;index:idx_refund_order_id"`
+	Amount     Money     `gorm:"type:decimal(12,2);not null;comment:退款金额（订单币种）"`
+	BaseAmount Money     `gorm:"type:decimal(12,2);not null;comment:退款金额（基准币种）"`
+	Reason     string    `gorm:"type:varchar(255);not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// RefundableAmount 订单剩余可退金额（订单币种）
+func (o *Order) RefundableAmount() Money {
+	remaining, _ := o.Amount.Sub(o.RefundedAmount)
+	return remaining
+}
+
+// Refund 退款 amount（订单币种），返回退款记录，退款的基准币种金额需要从消费总额中扣除
+// 只有已支付（paid / shipped / completed）的订单可以退款；累计退款达到订单金额时订单变为 refunded
+func (o *Order) Refund(amount Money, reason string) (*Refund, error) {
+	if amount.IsNegative() || amount.IsZero() {
+		return nil, ErrInvalidAmount.With("amount", amount)
+	}
+	if err := o.Currency.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrInvalidRefundReason
+	}
+	if utf8.RuneCountInString(reason) > maxRefundReasonLength {
+		return nil, ErrInvalidRefundReason.With("max_length", maxRefundReasonLength)
+	}
+	if !o.Status.CanTransitionTo(OrderRefunded) {
+		return nil, ErrInvalidTransition.With("from", o.Status).With("to", OrderRefunded)
+	}
+
+	remaining := o.RefundableAmount()
+	if amount.Cmp(remaining) > 0 {
+		return nil, ErrRefundExceedsPaid.With("requested", amount).With("remaining", remaining)
+	}
+
+	remainingBase, err := o.BaseAmount.Sub(o.RefundedBaseAmount)
+	if err != nil {
+		return nil, err
+	}
+	var baseAmount Money
+	if amount == remaining {
+		// 全额退完时直接退回剩余的基准币种金额，避免多次部分退款的舍入误差
+		baseAmount = remainingBase
+	} else {
+		if baseAmount, err = o.ExchangeRate.Convert(amount); err != nil {
+			return nil, err
+		}
+		if baseAmount.Cmp(remainingBase) > 0 {
+			baseAmount = remainingBase
+		}
+	}
+
+	if o.RefundedAmount, err = o.RefundedAmount.Add(amount); err != nil {
+		return nil, err
+	}
+	if o.RefundedBaseAmount, err = o.RefundedBaseAmount.Add(baseAmount); err != nil {
+		return nil, err
+	}
+	if amount == remaining {
+		o.Status = OrderRefunded
+	}
+	return &Refund{
+		OrderID:    o.OrderID,
+		Amount:     amount,
+		BaseAmount: baseAmount,
+		Reason:     reason,
+	}, nil
+}
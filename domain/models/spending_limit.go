@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SpendingWindow 滚动消费上限的统计区间
+const SpendingWindow = 30 * 24 * time.Hour
+
+// LimitKind 消费限额的种类
+type LimitKind string
+
+const (
+	LimitSingleOrder LimitKind = "single_order" // 单笔订单上限
+	LimitRolling30d  LimitKind = "rolling_30d"  // 最近30天累计上限
+)
+
+// LimitExceededError 下单金额超过某项限额（金额均为基准币种）
+type LimitExceededError struct {
+	Kind      LimitKind
+	Limit     Money // 限额
+	Current   Money // 统计区间内已计入的金额，单笔上限时为0
+	Requested Money // 本次下单金额
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Kind == LimitSingleOrder {
+		return fmt.Sprintf("单笔订单金额%s超过上限%s", e.Requested, e.Limit)
+	}
+	return fmt.Sprintf("最近30天消费%s加上本次%s超过上限%s", e.Current, e.Requested, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrLimitExceeded) 成立
+func (e *LimitExceededError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == CodeLimitExceeded
+}
+
+// As 使 errors.As 可以取出对应的 *DomainError（错误码 LIMIT_EXCEEDED）
+func (e *LimitExceededError) As(target any) bool {
+	t, ok := target.(**DomainError)
+	if ok {
+		*t = ErrLimitExceeded.With("kind", e.Kind).With("limit", e.Limit).
+			With("current", e.Current).With("requested", e.Requested)
+	}
+	return ok
+}
+
+// SpendingLimits 消费限额（基准币种），为0表示不限制
+type SpendingLimits struct {
+	MaxOrderAmount Money `json:"maxOrderAmount"` // 单笔订单上限
+	RollingCap     Money `json:"rollingCap"`     // 最近30天累计上限
+}
+
+// Validate 限额不能为负数
+func (l SpendingLimits) Validate() error {
+	if l.MaxOrderAmount.IsNegative() || l.RollingCap.IsNegative() {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// Check 检查本次下单金额 amount 是否超过限额，recent 为统计区间内已计入的金额（见 RecentSpending）
+func (l SpendingLimits) Check(amount Money, recent Money) error {
+	if !l.MaxOrderAmount.IsZero() && amount.Cmp(l.MaxOrderAmount) > 0 {
+		return &LimitExceededError{Kind: LimitSingleOrder, Limit: l.MaxOrderAmount, Requested: amount}
+	}
+	if !l.RollingCap.IsZero() {
+		total, err := recent.Add(amount)
+		if err != nil || total.Cmp(l.RollingCap) > 0 {
+			return &LimitExceededError{Kind: LimitRolling30d, Limit: l.RollingCap, Current: recent, Requested: amount}
+		}
+	}
+	return nil
+}
+
+// EffectiveLimits 用户实际适用的限额：单独设置的限额优先，未设置的使用 defaults
+func (u *User) EffectiveLimits(defaults SpendingLimits) SpendingLimits {
+	limits := defaults
+	if u.MaxOrderAmount != nil {
+		limits.MaxOrderAmount = *u.MaxOrderAmount
+	}
+	if u.RollingSpendingCap != nil {
+		limits.RollingCap = *u.RollingSpendingCap
+	}
+	return limits
+}
+
+// SetSpendingLimits 设置用户的限额，参数为 nil 表示恢复使用默认限额
+func (u *User) SetSpendingLimits(maxOrderAmount *Money, rollingCap *Money) error {
+	for _, limit := range []*Money{maxOrderAmount, rollingCap} {
+		if limit != nil && limit.IsNegative() {
+			return ErrInvalidLimit.With("limit", *limit)
+		}
+	}
+	u.MaxOrderAmount = maxOrderAmount
+	u.RollingSpendingCap = rollingCap
+	return nil
+}
+
+// RecentSpending 订单中计入消费总额的金额之和（扣除已取消、已退款的部分）
+func RecentSpending(orders []*Order) Money {
+	var total Money
+	for _, order := range orders {
+		if !order.IsActive() {
+			continue
+		}
+		total, _ = total.Add(order.countedAmount())
+	}
+	return total
+}
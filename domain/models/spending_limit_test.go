@@ -0,0 +1,66 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpendingLimits_Check(t *testing.T) {
+	limits := models.SpendingLimits{
+		MaxOrderAmount: models.MustParseMoney("1000"),
+		RollingCap:     models.MustParseMoney("3000"),
+	}
+
+	assert.NoError(t, limits.Check(models.MustParseMoney("1000"), models.MustParseMoney("2000")))
+
+	err := limits.Check(models.MustParseMoney("1000.01"), models.Money{})
+	assert.True(t, errors.Is(err, models.ErrLimitExceeded))
+	var limitErr *models.LimitExceededError
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, models.LimitSingleOrder, limitErr.Kind)
+		assert.Equal(t, models.MustParseMoney("1000"), limitErr.Limit)
+	}
+
+	err = limits.Check(models.MustParseMoney("500"), models.MustParseMoney("2600"))
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, models.LimitRolling30d, limitErr.Kind)
+		assert.Equal(t, models.MustParseMoney("2600"), limitErr.Current)
+		assert.Equal(t, models.MustParseMoney("500"), limitErr.Requested)
+	}
+
+	// 0 表示不限制
+	assert.NoError(t, models.SpendingLimits{}.Check(models.MustParseMoney("99999999"), models.MustParseMoney("99999999")))
+}
+
+func TestUser_EffectiveLimits(t *testing.T) {
+	defaults := models.SpendingLimits{
+		MaxOrderAmount: models.MustParseMoney("1000"),
+		RollingCap:     models.MustParseMoney("3000"),
+	}
+	user := &models.User{}
+	assert.Equal(t, defaults, user.EffectiveLimits(defaults))
+
+	unlimited := models.Money{}
+	assert.NoError(t, user.SetSpendingLimits(&unlimited, nil))
+	limits := user.EffectiveLimits(defaults)
+	assert.True(t, limits.MaxOrderAmount.IsZero())
+	assert.Equal(t, defaults.RollingCap, limits.RollingCap)
+
+	negative := models.MustParseMoney("-1")
+	assert.Error(t, user.SetSpendingLimits(nil, &negative))
+	assert.NotNil(t, user.MaxOrderAmount, "校验失败时不修改")
+}
+
+func TestRecentSpending(t *testing.T) {
+	orders := []*models.Order{
+		{BaseAmount: models.MustParseMoney("100"), Status: models.OrderPaid},
+		{BaseAmount: models.MustParseMoney("200"), Status: models.OrderCancelled},
+		{BaseAmount: models.MustParseMoney("300"), RefundedBaseAmount: models.MustParseMoney("50"), Status: models.OrderCompleted},
+		{BaseAmount: models.MustParseMoney("400"), RefundedBaseAmount: models.MustParseMoney("400"), Status: models.OrderRefunded},
+	}
+	assert.Equal(t, models.MustParseMoney("350"), models.RecentSpending(orders))
+	assert.True(t, models.RecentSpending(nil).IsZero())
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTierLength 等级名称的最大长度，与 tier_thresholds.tier 列一致
+const maxTierLength = 20
+
+// TierThreshold 客户等级的门槛：消费总额（基准币种）不低于 MinConsumption 即达到该等级
+type TierThreshold struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement"`
+	Tier           string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_tier_name"`
+	MinConsumption Money     `gorm:"type:decimal(12,2);not null;uniqueIndex:idx_tier_min;comment:达到该等级的最低消费总额"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// NewTierThreshold: 创建等级门槛
+func NewTierThreshold(tier string, minConsumption Money) (*TierThreshold, error) {
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	if tier == "" {
+		return nil, ErrInvalidTier.With("field", "tier")
+	}
+	if len(tier) > maxTierLength {
+		return nil, ErrInvalidTier.With("field", "tier").With("max_length", maxTierLength)
+	}
+	if minConsumption.IsNegative() {
+		return nil, ErrInvalidTier.With("field", "min_consumption")
+	}
+	return &TierThreshold{Tier: tier, MinConsumption: minConsumption}, nil
+}
+
+// TierSchedule 全部等级门槛
+type TierSchedule []TierThreshold
+
+// TierFor 消费总额对应的等级：不高于 total 的最高门槛；低于所有门槛时为空（无等级）
+func (s TierSchedule) TierFor(total Money) string {
+	sorted := make(TierSchedule, len(s))
+	copy(sorted, s)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MinConsumption.Cmp(sorted[j].MinConsumption) < 0
+	})
+
+	tier := ""
+	for _, threshold := range sorted {
+		if total.Cmp(threshold.MinConsumption) < 0 {
+			break
+		}
+		tier = threshold.Tier
+	}
+	return tier
+}
+
+// TierChange 用户等级变化记录
+type TierChange struct {
+	ID               uint64    `gorm:"primaryKey;autoIncrement"`
+	UserID           uint64    `gorm:"not null;index:idx_tier_change_user_id"`
+	FromTier         string    `gorm:"type:varchar(20);not null"`
+	ToTier           string    `gorm:"type:varchar(20);not null"`
+	TotalConsumption Money     `gorm:"type:decimal(12,2);not null;comment:变化时的消费总额"`
+	OrderID          uint64    `gorm:"not null;default:0;comment:引起变化的订单"`
+	ChangedAt        time.Time `gorm:"autoCreateTime"`
+}
+
+// RecalculateTier 按当前消费总额重新计算等级，等级变化时返回变化记录，未变化时返回 nil
+func (u *User) RecalculateTier(schedule TierSchedule, orderID uint64) *TierChange {
+	tier := schedule.TierFor(u.TotalConsumption)
+	if tier == u.Tier {
+		return nil
+	}
+	change := &TierChange{
+		UserID:           u.ID,
+		FromTier:         u.Tier,
+		ToTier:           tier,
+		TotalConsumption: u.TotalConsumption,
+		OrderID:          orderID,
+	}
+	u.Tier = tier
+	return change
+}
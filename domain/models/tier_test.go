@@ -0,0 +1,82 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTierSchedule() models.TierSchedule {
+	// 故意打乱顺序，TierFor 不依赖仓储的排序
+	return models.TierSchedule{
+		{Tier: "gold", MinConsumption: models.MustParseMoney("10000")},
+		{Tier: "bronze", MinConsumption: models.MustParseMoney("100")},
+		{Tier: "silver", MinConsumption: models.MustParseMoney("1000")},
+	}
+}
+
+func TestTierSchedule_TierFor(t *testing.T) {
+	schedule := testTierSchedule()
+	cases := []struct {
+		total string
+		want  string
+	}{
+		{"0", ""},
+		{"99.99", ""},
+		{"100", "bronze"},
+		{"999.99", "bronze"},
+		{"1000", "silver"},
+		{"10000", "gold"},
+		{"123456.78", "gold"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, schedule.TierFor(models.MustParseMoney(c.total)), c.total)
+	}
+	assert.Equal(t, "", models.TierSchedule{}.TierFor(models.MustParseMoney("100")))
+}
+
+func TestNewTierThreshold(t *testing.T) {
+	threshold, err := models.NewTierThreshold("  Gold ", models.MustParseMoney("10000"))
+	assert.NoError(t, err)
+	assert.Equal(t, "gold", threshold.Tier)
+
+	_, err = models.NewTierThreshold(" ", models.MustParseMoney("1"))
+	assert.Error(t, err)
+	_, err = models.NewTierThreshold("platinum-plus-premium", models.MustParseMoney("1"))
+	assert.Error(t, err)
+	_, err = models.NewTierThreshold("gold", models.MustParseMoney("-1"))
+	assert.Error(t, err)
+}
+
+func TestUser_RecalculateTier(t *testing.T) {
+	schedule := testTierSchedule()
+
+	t.Run("升级时返回变化记录", func(t *testing.T) {
+		user := &models.User{ID: 7, Tier: "bronze", TotalConsumption: models.MustParseMoney("1500")}
+		change := user.RecalculateTier(schedule, 1001)
+		if assert.NotNil(t, change) {
+			assert.Equal(t, uint64(7), change.UserID)
+			assert.Equal(t, "bronze", change.FromTier)
+			assert.Equal(t, "silver", change.ToTier)
+			assert.Equal(t, models.MustParseMoney("1500"), change.TotalConsumption)
+			assert.Equal(t, uint64(1001), change.OrderID)
+		}
+		assert.Equal(t, "silver", user.Tier)
+	})
+
+	t.Run("降到所有门槛以下时变为无等级", func(t *testing.T) {
+		user := &models.User{ID: 7, Tier: "bronze", TotalConsumption: models.MustParseMoney("50")}
+		change := user.RecalculateTier(schedule, 1002)
+		if assert.NotNil(t, change) {
+			assert.Equal(t, "", change.ToTier)
+		}
+		assert.Equal(t, "", user.Tier)
+	})
+
+	t.Run("等级未变化时返回nil", func(t *testing.T) {
+		user := &models.User{ID: 7, Tier: "silver", TotalConsumption: models.MustParseMoney("2000")}
+		assert.Nil(t, user.RecalculateTier(schedule, 1003))
+		assert.Equal(t, "silver", user.Tier)
+	})
+}
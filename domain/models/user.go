@@ -1,64 +1,220 @@
 package models
 
 import (
-	"errors"
 	"strings"
-	// "gorm.io/gorm"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// UserStatus 用户状态
+type UserStatus string
+
+const (
+	UserActive    UserStatus = "active"    // 正常
+	UserSuspended UserStatus = "suspended" // 已停用，可以恢复
+	UserDeleted   UserStatus = "deleted"   // 已注销（软删除，终态）
 )
 
+// maxUserNameLength 用户名的最大长度（字符数），与 users.name 列一致
+const maxUserNameLength = 100
+
 type User struct {
 	// gorm.Model  // 这个会引入CreatedAt、UpdatedAt等字段从而改变表结构
-	ID               uint64  `gorm:"primaryKey;autoIncrement"`
-	Name             string  `gorm:"type:varchar(100)"`
-	Email            string  `gorm:"uniqueIndex;type:varchar(255)"` // 明确指定类型和长度
-	TotalConsumption float64 `gorm:"type:decimal(12,2);default:0"`
+	ID    uint64 `gorm:"primaryKey;autoIncrement"`
+	Name  string `gorm:"type:varchar(100)"`
+	Email string `gorm:"type:varchar(255)"` // 用户输入的邮箱，用于展示与发送邮件
+	// 规范化后的邮箱（见 EmailPolicy.Canonicalize），唯一约束建立在规范形式上，大小写不同的邮箱不能重复注册
+	CanonicalEmail   string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_users_canonical_email"`
+	TotalConsumption Money      `gorm:"type:decimal(12,2);default:0"`
+	Version          uint64     `gorm:"not null;default:0"` // 乐观锁版本号，每次更新加1
+	Status           UserStatus `gorm:"type:varchar(20);not null;default:active;index:idx_user_status"`
+	Tier             string     `gorm:"type:varchar(20);not null;default:'';index:idx_user_tier"` // 客户等级，由消费总额计算（见 TierSchedule）
+	// 单独设置的消费限额（基准币种），为空时使用全局默认值（见 EffectiveLimits）
+	MaxOrderAmount     *Money `gorm:"type:decimal(12,2);comment:单笔订单上限"`
+	RollingSpendingCap *Money `gorm:"type:decimal(12,2);comment:最近30天累计消费上限"`
+	// 软删除：用户有订单（fk_user_id），不能物理删除；仓储默认过滤已删除的用户
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_user_deleted_at"`
+	// 个人信息的抹除时间，为空表示未抹除（见 Anonymize）
+	ErasedAt *time.Time
+
+	eventRecorder `gorm:"-"` // 领域事件，不持久化
 }
 
-// CreateUser: 创建用户
-func CreateUser(name string, email string) (*User, error) {
-	if isValidEmail(email) == false {
-		return nil, errors.New("邮箱格式不正确")
+// CreateUser: 创建用户，邮箱按 policy 校验与规范化，policy 为空时使用 DefaultEmailPolicy
+func CreateUser(name string, email string, policy EmailPolicy) (*User, error) {
+	if policy == nil {
+		policy = DefaultEmailPolicy()
 	}
-	return &User{
+	name, err := NormalizeUserName(name)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.TrimSpace(email)
+	if err := policy.Validate(email); err != nil {
+		return nil, err
+	}
+	user := &User{
 		ID:               uint64(0),
 		Name:             name,
 		Email:            email,
-		TotalConsumption: 0,
-	}, nil
+		CanonicalEmail:   policy.Canonicalize(email),
+		TotalConsumption: Money{},
+		Status:           UserActive,
+	}
+	user.record(func() DomainEvent {
+		return UserRegistered{UserID: user.ID, Email: user.Email}
+	})
+	return user, nil
+}
+
+// NormalizeUserName 去掉首尾空白并转为 Unicode NFC 形式，使组合字符与预组合字符的同一名字存储一致
+// 长度按规范化后的字符数计算，不能超过 users.name 列的长度
+func NormalizeUserName(name string) (string, error) {
+	name = norm.NFC.String(strings.TrimSpace(name))
+	if name == "" {
+		return "", ErrInvalidUserName.With("reason", "empty")
+	}
+	if utf8.RuneCountInString(name) > maxUserNameLength {
+		return "", ErrInvalidUserName.With("reason", "too_long").With("max_length", maxUserNameLength)
+	}
+	return name, nil
+}
+
+// Rename 修改用户名，返回用户名是否发生变化
+func (u *User) Rename(name string) (bool, error) {
+	name, err := NormalizeUserName(name)
+	if err != nil {
+		return false, err
+	}
+	if name == u.Name {
+		return false, nil
+	}
+	u.Name = name
+	return true, nil
 }
 
-// CreateOrder: 用户创建订单
-func (u *User) CreateOrder(userid uint64, amount float64) (*Order, error) {
-	if amount < 0 {
-		return nil, errors.New("消费金额不能为负数")
+// ApplyEmailChange 使已确认的邮箱变更生效
+func (u *User) ApplyEmailChange(r *EmailChangeRequest) error {
+	if r.UserID != u.ID {
+		return ErrEmailChangeMismatch.With("user_id", u.ID)
+	}
+	if r.ConfirmedAt == nil {
+		return ErrEmailChangeMismatch.With("user_id", u.ID).With("reason", "not_confirmed")
 	}
+	u.Email = r.NewEmail
+	u.CanonicalEmail = r.CanonicalEmail
+	return nil
+}
 
-	return &Order{
-		OrderID: 0,
-		UserID:  userid,
-		Amount:  amount,
-		IsValid: true,
-	}, nil
+// IsActive 用户是否为正常状态；未设置状态时按数据库默认值视为正常
+func (u *User) IsActive() bool {
+	return u.Status == UserActive || u.Status == ""
 }
 
-// AddConsumption: 修改消费总额
-func (u *User) AddConsumption(amount float64) error {
-	u.TotalConsumption += amount
-	if u.TotalConsumption < 0 {
-		return errors.New("消费总额不足")
+// Suspend 停用用户，停用后不能下单
+func (u *User) Suspend() error {
+	if !u.IsActive() {
+		return ErrInvalidStatusChange.With("from", u.Status).With("to", UserSuspended)
+	}
+	u.Status = UserSuspended
+	return nil
+}
+
+// Reactivate 恢复已停用的用户
+func (u *User) Reactivate() error {
+	if u.Status != UserSuspended {
+		return ErrInvalidStatusChange.With("from", u.Status).With("to", UserActive)
+	}
+	u.Status = UserActive
+	return nil
+}
+
+// Delete 注销用户（软删除），已注销的用户不能恢复
+func (u *User) Delete(now time.Time) error {
+	if u.Status == UserDeleted {
+		return ErrInvalidStatusChange.With("from", u.Status).With("to", UserDeleted)
 	}
+	u.Status = UserDeleted
+	u.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
 	return nil
 }
 
-func isValidEmail(email string) bool {
-	// 检查后缀
-	suffixes := []string{"@qq.com", "@163.com", "@example.com"}
-	for _, suffix := range suffixes {
-		if strings.HasSuffix(email, suffix) {
-			// 确保@前至少有一个字符，且整个字符串只有一个@
-			atIndex := len(email) - len(suffix)
-			return atIndex > 0 && !strings.Contains(email[:atIndex], "@")
+// CreateOrder: 用户创建订单，amount 为 rate.Currency 币种的金额，按 rate 折算为基准币种
+func (u *User) CreateOrder(userid uint64, amount Money, rate *ExchangeRate) (*Order, error) {
+	if !u.IsActive() {
+		return nil, ErrUserNotActive.With("status", u.Status)
+	}
+	if amount.IsNegative() {
+		return nil, ErrInvalidAmount.With("amount", amount)
+	}
+	if err := rate.Currency.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+	baseAmount, err := rate.Convert(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &Order{
+		OrderID:      0,
+		UserID:       userid,
+		Amount:       amount,
+		Currency:     rate.Currency,
+		ExchangeRate: rate.Rate,
+		BaseAmount:   baseAmount,
+		Status:       OrderPending,
+	}
+	event := order.orderEvent()
+	order.record(func() DomainEvent {
+		event.OrderID = order.OrderID // 订单ID在保存后分配
+		return OrderCreated{event}
+	})
+	return order, nil
+}
+
+// CreateOrderFromItems: 用户按订单明细创建订单，订单金额为各明细小计之和
+// 明细的币种必须与 rate.Currency 一致
+func (u *User) CreateOrderFromItems(userid uint64, items []OrderItem, rate *ExchangeRate) (*Order, error) {
+	if len(items) == 0 {
+		return nil, ErrInvalidOrderItems
+	}
+	var amount Money
+	for _, item := range items {
+		if item.Currency != rate.Currency {
+			return nil, ErrCurrencyMismatch.With("sku", item.SKU).With("currency", item.Currency).With("order_currency", rate.Currency)
+		}
+		var err error
+		if amount, err = amount.Add(item.Subtotal); err != nil {
+			return nil, err
 		}
 	}
-	return false
+
+	order, err := u.CreateOrder(userid, amount, rate)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+	return order, nil
+}
+
+// AddConsumption: 修改消费总额
+// 与 UserRepository.AddTotalConsumption 的规则一致：扣减后为负数时拒绝且不修改
+func (u *User) AddConsumption(amount Money) error {
+	total, err := u.TotalConsumption.Add(amount)
+	if err != nil {
+		return err
+	}
+	if total.IsNegative() {
+		return ErrInsufficientConsumption
+	}
+	u.TotalConsumption = total
+	if !amount.IsZero() {
+		u.record(func() DomainEvent {
+			return ConsumptionChanged{UserID: u.ID, Delta: amount}
+		})
+	}
+	return nil
 }
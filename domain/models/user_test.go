@@ -2,6 +2,7 @@ package models_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/stretchr/testify/assert"
@@ -11,22 +12,48 @@ func TestUser_CreateOrder(t *testing.T) {
 	// 正常情况测试
 	t.Run("用户正确创建订单", func(t *testing.T) {
 		user := &models.User{ID: 1001}
-		amount := 100
+		amount := models.MustParseMoney("100")
 
-		order, err := user.CreateOrder(user.ID, float64(amount))
+		order, err := user.CreateOrder(user.ID, amount, models.BaseExchangeRate())
 		// 比对
 		assert.NoError(t, err)
 		assert.Equal(t, user.ID, order.UserID)
-		assert.Equal(t, float64(amount), order.Amount)
-		assert.True(t, order.IsValid)
+		assert.Equal(t, amount, order.Amount)
+		assert.Equal(t, models.BaseCurrency, order.Currency)
+		assert.Equal(t, amount, order.BaseAmount)
+		assert.Equal(t, models.OrderPending, order.Status)
+	})
+	t.Run("外币订单折算为基准币种", func(t *testing.T) {
+		user := &models.User{ID: 1001}
+		rate := &models.ExchangeRate{Currency: models.USD, Rate: models.MustParseRate("7.1234")}
+
+		order, err := user.CreateOrder(user.ID, models.MustParseMoney("10.01"), rate)
+		assert.NoError(t, err)
+		assert.Equal(t, models.USD, order.Currency)
+		assert.Equal(t, models.MustParseMoney("10.01"), order.Amount)
+		assert.Equal(t, models.MustParseRate("7.1234"), order.ExchangeRate)
+		// 10.01 * 7.1234 = 71.305234
+		assert.Equal(t, models.MustParseMoney("71.31"), order.BaseAmount)
+	})
+	t.Run("日元金额不能有小数", func(t *testing.T) {
+		user := &models.User{ID: 1001}
+		rate := &models.ExchangeRate{Currency: models.JPY, Rate: models.MustParseRate("0.04821")}
+
+		_, err := user.CreateOrder(user.ID, models.MustParseMoney("100.50"), rate)
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
+		assert.Equal(t, models.CategoryValidation, models.CategoryOf(err))
+
+		order, err := user.CreateOrder(user.ID, models.MustParseMoney("100"), rate)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("4.82"), order.BaseAmount)
 	})
 	t.Run("金额为负数应返回错误", func(t *testing.T) {
 		user := models.User{ID: 1002}
-		amount := -50.0
+		amount := models.MustParseMoney("-50")
 
-		order, err := user.CreateOrder(user.ID, amount)
+		order, err := user.CreateOrder(user.ID, amount, models.BaseExchangeRate())
 
-		assert.ErrorContains(t, err, "消费金额不能为负数")
+		assert.ErrorIs(t, err, models.ErrInvalidAmount)
 		assert.Nil(t, order)
 	})
 }
@@ -34,20 +61,71 @@ func TestUser_CreateOrder(t *testing.T) {
 func TestUser_AddConsumption(t *testing.T) {
 	// 正常情况测试
 	t.Run("PositiveAmount", func(t *testing.T) {
-		user := &models.User{TotalConsumption: 100}
-		if err := user.AddConsumption(50); err != nil {
+		user := &models.User{TotalConsumption: models.MustParseMoney("100")}
+		if err := user.AddConsumption(models.MustParseMoney("50")); err != nil {
 			t.Fatalf("添加金额失败: %v", err)
 		}
-		if user.TotalConsumption != 150 {
-			t.Errorf("期望 150，实际 %.2f", user.TotalConsumption)
+		if user.TotalConsumption != models.MustParseMoney("150") {
+			t.Errorf("期望 150，实际 %s", user.TotalConsumption)
 		}
 	})
 
 	// 异常情况测试
 	t.Run("NegativeAmount", func(t *testing.T) {
-		user := &models.User{TotalConsumption: 100}
-		if err := user.AddConsumption(-120); err == nil {
+		user := &models.User{TotalConsumption: models.MustParseMoney("100")}
+		if err := user.AddConsumption(models.MustParseMoney("-120")); err == nil {
 			t.Error("余额不足错误未触发")
 		}
+		if user.TotalConsumption != models.MustParseMoney("100") {
+			t.Errorf("余额不足时不应修改消费总额，实际 %s", user.TotalConsumption)
+		}
 	})
 }
+
+func TestUser_Status(t *testing.T) {
+	t.Run("停用后不能下单，恢复后可以", func(t *testing.T) {
+		user := &models.User{ID: 1001, Status: models.UserActive}
+		assert.NoError(t, user.Suspend())
+		_, err := user.CreateOrder(user.ID, models.MustParseMoney("100"), models.BaseExchangeRate())
+		assert.ErrorIs(t, err, models.ErrUserNotActive)
+
+		assert.NoError(t, user.Reactivate())
+		_, err = user.CreateOrder(user.ID, models.MustParseMoney("100"), models.BaseExchangeRate())
+		assert.NoError(t, err)
+	})
+
+	t.Run("注销", func(t *testing.T) {
+		user := &models.User{ID: 1001, Status: models.UserSuspended}
+		now := time.Now()
+		assert.NoError(t, user.Delete(now))
+		assert.Equal(t, models.UserDeleted, user.Status)
+		assert.True(t, user.DeletedAt.Valid)
+		assert.Equal(t, now, user.DeletedAt.Time)
+
+		assert.Error(t, user.Delete(now))
+		assert.Error(t, user.Reactivate())
+		assert.Error(t, user.Suspend())
+		assert.False(t, user.IsActive())
+	})
+
+	t.Run("正常状态的用户不能恢复", func(t *testing.T) {
+		assert.Error(t, (&models.User{Status: models.UserActive}).Reactivate())
+	})
+}
+
+func TestUser_Rename(t *testing.T) {
+	user := &models.User{Name: "René"}
+
+	changed, err := user.Rename("René")
+	assert.NoError(t, err)
+	assert.False(t, changed, "规范化后相同的用户名不算变化")
+
+	changed, err = user.Rename("Alice")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Alice", user.Name)
+
+	_, err = user.Rename("")
+	assert.Error(t, err)
+	assert.Equal(t, "Alice", user.Name)
+}
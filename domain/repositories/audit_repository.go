@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// AuditRepository 审计记录的数据访问契约，审计记录只增不改
+type AuditRepository interface {
+	Save(ctx context.Context, entry *models.AuditEntry) (uint64, error) // 返回审计记录ID
+	// FindBySubject 按时间先后返回对象的审计记录，没有记录时返回空切片
+	FindBySubject(ctx context.Context, subjectType string, subjectID uint64) ([]*models.AuditEntry, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// EmailChangeRepository 邮箱变更请求的数据访问契约
+type EmailChangeRepository interface {
+	Save(ctx context.Context, req *models.EmailChangeRequest) (uint64, error) // 返回请求ID
+	// FindByTokenHash 按令牌摘要查询，不存在返回 ErrorNotFound
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error)
+	// MarkConfirmed 写入 req.ConfirmedAt，仅当数据库中尚未确认时生效，否则返回 models.ErrEmailChangeConfirmed
+	MarkConfirmed(ctx context.Context, req *models.EmailChangeRequest) error
+	// CancelPending 删除用户尚未确认的请求，使其令牌失效，返回删除条数
+	CancelPending(ctx context.Context, userID uint64) (int64, error)
+	// DeleteByUserID 删除用户的全部邮箱变更请求，返回删除条数
+	DeleteByUserID(ctx context.Context, userID uint64) (int64, error)
+}
@@ -1,8 +1,18 @@
 package repositories
 
-import "errors"
+import "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 
+// 仓储层的通用错误，同样是 *models.DomainError：应用服务通常用对象相关的错误（如 models.ErrUserNotFound）包装后返回，
+// errors.Is 仍可判断原因
 var (
-	ErrorNotFound = errors.New("Not Found")
-	ErrorInvalid  = errors.New("Invalid")
+	ErrorNotFound = models.NewDomainError("NOT_FOUND", models.CategoryNotFound, "Not Found")
+	ErrorInvalid  = models.NewDomainError("INVALID", models.CategoryValidation, "Invalid")
+	// ErrorVersionConflict 乐观锁冲突：记录在读取之后已被其他事务修改，调用方应重新读取后重试
+	ErrorVersionConflict = models.NewDomainError("VERSION_CONFLICT", models.CategoryConflict, "Version Conflict")
+	// ErrorNoTransaction 需要在事务中调用的方法（如 FindByIDForUpdate）在事务外被调用
+	ErrorNoTransaction = models.NewDomainError("NO_TRANSACTION", models.CategoryInternal, "No Transaction")
+	// ErrorLocked 以 LockNoWait 加锁时记录已被其他事务锁定
+	ErrorLocked = models.NewDomainError("LOCKED", models.CategoryConflict, "Locked")
+	// ErrorDuplicate 违反唯一约束
+	ErrorDuplicate = models.NewDomainError("DUPLICATE", models.CategoryConflict, "Duplicate")
 )
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// EventDispatcher 把已提交的领域事件分发给进程内的订阅者
+// 与发件箱不同，进程退出时尚未处理的异步事件会丢失，需要可靠投递的场景使用发件箱
+type EventDispatcher interface {
+	// Dispatch 按顺序把事件交给订阅者：同步订阅者在返回前执行完毕，异步订阅者在后台执行
+	// 返回同步订阅者的错误；调用时数据已提交，调用方不应因此回滚或重试
+	Dispatch(ctx context.Context, events ...models.DomainEvent) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// ExchangeRateRepository 汇率的数据访问契约
+type ExchangeRateRepository interface {
+	// FindEffective 返回 at 时刻生效的汇率（EffectiveAt <= at 中最新的一条），不存在返回 ErrorNotFound
+	FindEffective(ctx context.Context, currency models.Currency, at time.Time) (*models.ExchangeRate, error)
+	Save(ctx context.Context, rate *models.ExchangeRate) error // 同币种同生效时间已存在返回 ErrorDuplicate
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// IdempotencyRepository 幂等键的数据访问契约
+type IdempotencyRepository interface {
+	FindByKey(ctx context.Context, key string) (*models.IdempotencyRecord, error) // 不存在返回 ErrorNotFound
+	Save(ctx context.Context, record *models.IdempotencyRecord) error             // 键已存在返回 ErrorDuplicate
+}
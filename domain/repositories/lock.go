@@ -0,0 +1,10 @@
+package repositories
+
+// LockMode 行锁（SELECT ... FOR UPDATE）遇到已被其他事务锁定的行时的处理方式
+type LockMode int
+
+const (
+	LockWait       LockMode = iota // 等待锁释放（受 innodb_lock_wait_timeout 限制）
+	LockNoWait                     // NOWAIT：立即返回 ErrorLocked
+	LockSkipLocked                 // SKIP LOCKED：跳过被锁定的行，表现为 ErrorNotFound
+)
@@ -0,0 +1,9 @@
+package repositories
+
+import "context"
+
+// Logger 输出本地化的运行日志
+// key 为文案键（如 log.outbox_parked），params 替换文案中的 {name} 占位符；error 类型的参数按错误码的文案输出
+type Logger interface {
+	Log(ctx context.Context, key string, params map[string]any)
+}
@@ -1,13 +1,27 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	// "mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 )
 
 // OrderRepository 订单实体的数据访问契约
 type OrderRepository interface {
-	FindByID(orderID uint64) (*models.Order, error)
-	Save(order *models.Order) (uint64, error)                  // 返回订单ID
-	UpdateValidity(orderID uint64, isValid bool) (int8, error) // 返回影响的行数
+	FindByID(ctx context.Context, orderID uint64) (*models.Order, error) // 同时返回订单明细
+	// FindByIDForUpdate SELECT ... FOR UPDATE 查询并锁定订单，锁持有到事务结束
+	// 只能在事务中调用，否则返回 ErrorNoTransaction
+	FindByIDForUpdate(ctx context.Context, orderID uint64, mode LockMode) (*models.Order, error)
+	// FindByUserID 按订单ID升序返回用户的全部订单（含明细），没有订单时返回空切片
+	FindByUserID(ctx context.Context, userID uint64) ([]*models.Order, error)
+	// FindByUserIDSince 按订单ID升序返回用户在 since 之后（含）创建的订单，不含明细
+	FindByUserIDSince(ctx context.Context, userID uint64, since time.Time) ([]*models.Order, error)
+	Save(ctx context.Context, order *models.Order) (uint64, error) // 返回订单ID；不写入订单明细
+	// UpdateStatus 把状态从 from 更新为 order.Status，仅当数据库中的状态等于 from 且版本号等于 order.Version 时生效
+	// 返回影响的行数；状态或版本不一致返回 ErrorVersionConflict，成功后 order.Version 加1
+	UpdateStatus(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error)
+	// UpdateRefund 写入 order 的累计退款金额及状态，条件与 UpdateStatus 相同
+	UpdateRefund(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error)
 }
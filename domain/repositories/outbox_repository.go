@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// OutboxRepository 事务性发件箱的数据访问契约
+type OutboxRepository interface {
+	Save(ctx context.Context, msg *models.OutboxMessage) (uint64, error) // 返回消息ID
+	// FindPending 按ID升序返回在 now 可以发布的消息：未发布、未停放且已到重试时间
+	// 同一聚合中有更早的消息仍在等待重试时，该聚合的后续消息不返回，保证聚合内有序；停放的消息不阻塞后续消息
+	FindPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error)
+	MarkPublished(ctx context.Context, id uint64, publishedAt time.Time) error // 标记为已发布
+	// MarkFailed 记录一次发布失败，消息在 nextAttemptAt 之后重试
+	MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error
+	// MarkDead 记录最后一次发布失败并停放消息，之后不再发布
+	MarkDead(ctx context.Context, id uint64, reason string, deadAt time.Time) error
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) // 清理已发布消息，返回删除条数
+}
+
+// Publisher 把发件箱中的消息投递到外部系统（消息队列、文件等）
+type Publisher interface {
+	Publish(ctx context.Context, msg *models.OutboxMessage) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// ProductRepository 商品目录的数据访问契约
+type ProductRepository interface {
+	FindByID(ctx context.Context, id uint64) (*models.Product, error)   // 不存在返回 ErrorNotFound
+	FindBySKU(ctx context.Context, sku string) (*models.Product, error) // 不存在返回 ErrorNotFound
+	Save(ctx context.Context, product *models.Product) (uint64, error)  // 返回商品ID；SKU已存在返回 ErrorDuplicate
+	// DecrementStock 在一条语句中按条件扣减库存，库存不足返回 *models.OutOfStockError，商品不存在返回 ErrorNotFound
+	DecrementStock(ctx context.Context, productID uint64, quantity int) error
+	// IncrementStock 增加库存（补货、取消订单归还），商品不存在返回 ErrorNotFound
+	IncrementStock(ctx context.Context, productID uint64, quantity int) error
+	// FindLowStock 库存不高于 threshold 的商品，按库存从少到多排序
+	FindLowStock(ctx context.Context, threshold int) ([]*models.Product, error)
+}
+
+// OrderItemRepository 订单明细的数据访问契约
+type OrderItemRepository interface {
+	Save(ctx context.Context, items []models.OrderItem) error                      // 批量写入，写入后回填明细ID
+	FindByOrderID(ctx context.Context, orderID uint64) ([]models.OrderItem, error) // 按写入顺序返回
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// RefundRepository 退款记录的数据访问契约
+type RefundRepository interface {
+	Save(ctx context.Context, refund *models.Refund) (uint64, error)             // 返回退款记录ID
+	FindByOrderID(ctx context.Context, orderID uint64) ([]*models.Refund, error) // 按退款先后顺序返回，没有退款时返回空切片
+}
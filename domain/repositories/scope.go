@@ -0,0 +1,16 @@
+package repositories
+
+import "context"
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted 返回的 ctx 中，仓储的查询与更新包含已软删除的记录；默认会过滤已删除的记录
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted ctx 是否要求包含已软删除的记录
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
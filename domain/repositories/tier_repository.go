@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+)
+
+// TierRepository 客户等级门槛与等级变化记录的数据访问契约
+type TierRepository interface {
+	FindThresholds(ctx context.Context) (models.TierSchedule, error) // 按门槛升序返回全部等级
+	// SaveThreshold 按等级名称新增或修改门槛；门槛与其他等级相同时返回 ErrorDuplicate
+	SaveThreshold(ctx context.Context, threshold *models.TierThreshold) error
+	SaveChange(ctx context.Context, change *models.TierChange) (uint64, error)            // 返回记录ID
+	FindChangesByUserID(ctx context.Context, userID uint64) ([]*models.TierChange, error) // 按时间先后返回
+}
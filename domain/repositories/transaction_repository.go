@@ -1,5 +1,44 @@
 package repositories
 
+import (
+	"context"
+	"time"
+)
+
+// IsolationLevel 事务隔离级别
+type IsolationLevel int
+
+const (
+	IsolationDefault        IsolationLevel = iota // 使用数据库默认级别（MySQL 为 REPEATABLE READ）
+	IsolationReadCommitted                        // READ COMMITTED
+	IsolationRepeatableRead                       // REPEATABLE READ
+	IsolationSerializable                         // SERIALIZABLE
+)
+
+// TxOptions 事务选项，零值等价于 Transaction 的默认行为
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool          // 只读事务，事务内的写操作会被数据库拒绝
+	Timeout   time.Duration // 事务整体超时（含重试），<=0 表示不限制
+}
+
+// TransactionManager 统一事务接口
+// fn 收到的 ctx 携带了事务句柄，事务内的仓储调用必须使用该 ctx 才能参与同一事务
+//
+// 嵌套调用：在已开启事务的 ctx 上再次调用 Transaction 时不会开启新事务，
+// 而是在当前事务中创建 SAVEPOINT。内层 fn 返回错误只回滚到该 SAVEPOINT，
+// 外层可以选择吞掉该错误继续提交；外层返回错误则整个事务（含已成功的内层）回滚。
+//
+// panic：内层 fn 发生 panic 时先回滚到 SAVEPOINT，panic 不会被吞掉，
+// 会继续向外传播并导致最外层事务整体回滚，最终由最外层 Transaction 的调用方收到该 panic。
 type TransactionManager interface {
-	Transaction(func() error) error // 统一事务接口
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// TransactionWithOptions 按 opts 指定的隔离级别、只读模式与超时开启事务
+	// 嵌套调用时沿用外层事务的隔离级别与只读设置，只有 Timeout 生效
+	TransactionWithOptions(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
+	// TransactionDepth 返回 ctx 所处的事务嵌套深度：0 表示不在事务中，1 表示最外层事务
+	TransactionDepth(ctx context.Context) int
+	// AfterCommit 登记在最外层事务提交后执行的 fn，fn 收到的 ctx 不再携带事务句柄
+	// 事务回滚（含回滚到 SAVEPOINT 的内层事务、被重试的尝试）时登记的 fn 被丢弃；ctx 不在事务中时立即执行
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
 }
@@ -1,13 +1,29 @@
 package repositories
 
 import (
+	"context"
+
 	// "mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 )
 
 type UserRepository interface {
-	FindByID(id uint64) (*models.User, error)
-	FindByEmail(email string) (*models.User, error)         // 查询用户
-	Save(user *models.User) (uint64, error)                 // 保存用户信息, 返回用户ID
-	UpdateTotalConsumption(user *models.User) (int8, error) // 返回更新的条数
+	FindByID(ctx context.Context, id uint64) (*models.User, error)
+	// FindByIDForUpdate SELECT ... FOR UPDATE 查询并锁定用户，锁持有到事务结束
+	// 只能在事务中调用，否则返回 ErrorNoTransaction
+	FindByIDForUpdate(ctx context.Context, id uint64, mode LockMode) (*models.User, error)
+	// FindByEmail 按规范化后的邮箱（models.User.CanonicalEmail）查询用户
+	FindByEmail(ctx context.Context, canonicalEmail string) (*models.User, error)
+	// FindByTier 按用户ID升序分页返回指定等级的用户，tier 为空时返回无等级的用户
+	FindByTier(ctx context.Context, tier string, offset int, limit int) ([]*models.User, error)
+	// Save 保存用户信息, 返回用户ID；规范化邮箱已被其他用户使用时返回 ErrorDuplicate
+	// 用户须经 models.CreateUser 创建，规范化邮箱为空时返回 ErrorInvalid
+	Save(ctx context.Context, user *models.User) (uint64, error)
+	// UpdateFields 只更新 fields 中列出的字段（models.User 的字段名，如 "Name"），仅当数据库中的版本号等于 user.Version 时生效
+	// 返回更新的条数；版本不一致返回 ErrorVersionConflict，用户不存在返回 ErrorNotFound，
+	// 规范化邮箱已被其他用户使用返回 ErrorDuplicate；成功后 user.Version 加1
+	UpdateFields(ctx context.Context, user *models.User, fields ...string) (int8, error)
+	// AddTotalConsumption 在SQL中按增量原子修改消费总额，返回更新的条数
+	// 用户不存在返回 ErrorNotFound，扣减后为负数返回 models.ErrInsufficientConsumption
+	AddTotalConsumption(ctx context.Context, userID uint64, delta models.Money) (int8, error)
 }
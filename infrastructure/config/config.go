@@ -1,5 +1,7 @@
 package config
 
+import "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+
 type DatabaseConfig struct {
 	Host      string `json:"host"`
 	Port      int    `json:"port"`
@@ -10,6 +12,22 @@ type DatabaseConfig struct {
 	ParseTime bool   `json:"parseTime"`
 }
 
+// EmailConfig 注册邮箱的校验策略，全部为空时只校验语法
+type EmailConfig struct {
+	AllowDomains          []string `json:"allowDomains"` // 为空时不限制
+	DenyDomains           []string `json:"denyDomains"`
+	DisposableDomainsFile string   `json:"disposableDomainsFile"` // 一次性邮箱域名列表，每行一个，# 开头为注释
+	CheckMX               bool     `json:"checkMX"`               // 是否要求域名存在MX记录
+	// 规范化邮箱时的本地部分规则
+	IgnorePlusTag     bool     `json:"ignorePlusTag"`     // alice+tag@ 与 alice@ 视为同一个邮箱
+	IgnoreDotsDomains []string `json:"ignoreDotsDomains"` // 这些域名忽略本地部分中的点，如 gmail.com
+}
+
 type Config struct {
 	Database DatabaseConfig `json:"database"`
+	Email    EmailConfig    `json:"email"`
+	// 默认的消费限额（基准币种），为0表示不限制；用户单独设置的限额优先
+	Limits models.SpendingLimits `json:"limits"`
+	// 错误说明与日志的语言，如 zh-CN、en-US，为空时使用 zh-CN；单次请求可以通过 i18n.WithLocale 指定
+	Locale string `json:"locale"`
 }
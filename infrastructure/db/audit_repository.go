@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAuditRepository(db *gorm.DB) repositories.AuditRepository {
+	return &GormAuditRepository{db: db}
+}
+
+func (r *GormAuditRepository) Save(ctx context.Context, entry *models.AuditEntry) (uint64, error) {
+	if err := conn(ctx, r.db).Create(entry).Error; err != nil {
+		return uint64(0), err
+	}
+	return entry.ID, nil
+}
+
+func (r *GormAuditRepository) FindBySubject(ctx context.Context, subjectType string, subjectID uint64) ([]*models.AuditEntry, error) {
+	entries := []*models.AuditEntry{}
+	err := conn(ctx, r.db).Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).Order("id").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
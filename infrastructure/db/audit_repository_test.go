@@ -0,0 +1,48 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	if err := dbConn.AutoMigrate(&models.AuditEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM audit_entries").Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repo := db.NewGormAuditRepository(dbConn)
+
+	t.Run("按对象查询审计记录", func(t *testing.T) {
+		for _, subjectID := range []uint64{1001, 1002, 1001} {
+			entry, err := models.NewAuditEntry("admin", models.AuditUserErased, models.AggregateUser, subjectID, models.UserErasedDetail{})
+			assert.NoError(t, err)
+			_, err = repo.Save(ctx, entry)
+			assert.NoError(t, err)
+		}
+
+		entries, err := repo.FindBySubject(ctx, models.AggregateUser, 1001)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 2) {
+			assert.Less(t, entries[0].ID, entries[1].ID)
+			assert.JSONEq(t, `{"orders":0,"email_changes_removed":0}`, entries[0].Detail)
+		}
+
+		entries, err = repo.FindBySubject(ctx, models.AggregateOrder, 1001)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM audit_entries").Error; err != nil {
+		t.Fatal(err)
+	}
+}
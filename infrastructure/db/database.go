@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -25,3 +27,20 @@ func NewDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// exists 判断满足条件的记录是否存在，用于区分条件更新未生效的原因（记录不存在 / 条件不满足）
+func exists(tx *gorm.DB, model interface{}, query string, args ...interface{}) (bool, error) {
+	var count int64
+	if err := tx.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// scoped 按 ctx 决定查询是否包含已软删除的记录（repositories.IncludeDeleted）
+func scoped(ctx context.Context, tx *gorm.DB) *gorm.DB {
+	if repositories.IncludesDeleted(ctx) {
+		return tx.Unscoped()
+	}
+	return tx
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type GormEmailChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEmailChangeRepository(db *gorm.DB) repositories.EmailChangeRepository {
+	return &GormEmailChangeRepository{db: db}
+}
+
+func (r *GormEmailChangeRepository) Save(ctx context.Context, req *models.EmailChangeRequest) (uint64, error) {
+	if err := conn(ctx, r.db).Create(req).Error; err != nil {
+		return uint64(0), err
+	}
+	return req.ID, nil
+}
+
+func (r *GormEmailChangeRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error) {
+	var req models.EmailChangeRequest
+	if err := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrorNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *GormEmailChangeRepository) MarkConfirmed(ctx context.Context, req *models.EmailChangeRequest) error {
+	result := conn(ctx, r.db).Model(&models.EmailChangeRequest{}).
+		Where("id = ? AND confirmed_at IS NULL", req.ID).
+		Update("confirmed_at", req.ConfirmedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 并发确认时只有一个请求能生效
+		return models.ErrEmailChangeConfirmed
+	}
+	return nil
+}
+
+func (r *GormEmailChangeRepository) CancelPending(ctx context.Context, userID uint64) (int64, error) {
+	result := conn(ctx, r.db).Where("user_id = ? AND confirmed_at IS NULL", userID).Delete(&models.EmailChangeRequest{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormEmailChangeRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	result := conn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.EmailChangeRequest{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,93 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailChangeRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	if err := dbConn.AutoMigrate(&models.EmailChangeRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM email_change_requests").Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repo := db.NewGormEmailChangeRepository(dbConn)
+
+	user := &models.User{ID: 1001, Email: "a@qq.com", CanonicalEmail: "a@qq.com"}
+	req, token, err := models.NewEmailChangeRequest(user, "b@corp.com", nil, time.Hour, time.Now())
+	assert.NoError(t, err)
+
+	t.Run("按令牌摘要查询", func(t *testing.T) {
+		_, err := repo.Save(ctx, req)
+		assert.NoError(t, err)
+
+		found, err := repo.FindByTokenHash(ctx, models.HashEmailChangeToken(token))
+		assert.NoError(t, err)
+		assert.Equal(t, "b@corp.com", found.NewEmail)
+		assert.Nil(t, found.ConfirmedAt)
+
+		_, err = repo.FindByTokenHash(ctx, models.HashEmailChangeToken("unknown"))
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	t.Run("只能确认一次", func(t *testing.T) {
+		assert.NoError(t, req.Confirm(time.Now()))
+		assert.NoError(t, repo.MarkConfirmed(ctx, req))
+		assert.ErrorIs(t, repo.MarkConfirmed(ctx, req), models.ErrEmailChangeConfirmed)
+
+		found, err := repo.FindByTokenHash(ctx, req.TokenHash)
+		assert.NoError(t, err)
+		assert.NotNil(t, found.ConfirmedAt)
+	})
+
+	t.Run("新的请求使之前未确认的令牌失效", func(t *testing.T) {
+		older, olderToken, err := models.NewEmailChangeRequest(user, "d@corp.com", nil, time.Hour, time.Now())
+		assert.NoError(t, err)
+		_, err = repo.Save(ctx, older)
+		assert.NoError(t, err)
+
+		cancelled, err := repo.CancelPending(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), cancelled)
+		newer, newerToken, err := models.NewEmailChangeRequest(user, "e@corp.com", nil, time.Hour, time.Now())
+		assert.NoError(t, err)
+		_, err = repo.Save(ctx, newer)
+		assert.NoError(t, err)
+
+		_, err = repo.FindByTokenHash(ctx, models.HashEmailChangeToken(olderToken))
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+		_, err = repo.FindByTokenHash(ctx, models.HashEmailChangeToken(newerToken))
+		assert.NoError(t, err)
+		// 已确认的请求保留
+		_, err = repo.FindByTokenHash(ctx, req.TokenHash)
+		assert.NoError(t, err)
+	})
+
+	t.Run("删除用户的全部请求", func(t *testing.T) {
+		other, _, err := models.NewEmailChangeRequest(user, "c@corp.com", nil, time.Hour, time.Now())
+		assert.NoError(t, err)
+		_, err = repo.Save(ctx, other)
+		assert.NoError(t, err)
+
+		removed, err := repo.DeleteByUserID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), removed)
+		_, err = repo.FindByTokenHash(ctx, other.TokenHash)
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM email_change_requests").Error; err != nil {
+		t.Fatal(err)
+	}
+}
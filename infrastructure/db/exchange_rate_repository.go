@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type GormExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormExchangeRateRepository(db *gorm.DB) repositories.ExchangeRateRepository {
+	return &GormExchangeRateRepository{db: db}
+}
+
+func (r *GormExchangeRateRepository) FindEffective(ctx context.Context, currency models.Currency, at time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := conn(ctx, r.db).
+		Where("currency = ? AND effective_at <= ?", currency, at).
+		Order("effective_at DESC").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrorNotFound
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *GormExchangeRateRepository) Save(ctx context.Context, rate *models.ExchangeRate) error {
+	if err := conn(ctx, r.db).Create(rate).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+			return repositories.ErrorDuplicate
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestExchangeRateDB(t *testing.T) *gorm.DB {
+	// 使用测试的数据库
+	cfg := &config.DatabaseConfig{
+		Host:      "localhost",
+		Port:      3306,
+		User:      "gouser",
+		Password:  "StrongPass123!",
+		DBName:    "go_dev_test",
+		Charset:   "utf8mb4",
+		ParseTime: true,
+	}
+
+	dbConn, err := db.NewDB(cfg)
+	assert.NoError(t, err, "数据库连接失败")
+
+	if err := dbConn.AutoMigrate(&models.ExchangeRate{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dbConn.Exec("DELETE FROM exchange_rates").Error; err != nil {
+		t.Fatal(err)
+	}
+	return dbConn
+}
+
+func TestExchangeRateRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestExchangeRateDB(t)
+	ctx := context.Background()
+	repo := db.NewGormExchangeRateRepository(dbConn)
+
+	day1 := time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	for _, rate := range []*models.ExchangeRate{
+		{Currency: models.USD, Rate: models.MustParseRate("7.1"), EffectiveAt: day1},
+		{Currency: models.USD, Rate: models.MustParseRate("7.2"), EffectiveAt: day2},
+		{Currency: models.JPY, Rate: models.MustParseRate("0.04821"), EffectiveAt: day1},
+	} {
+		assert.NoError(t, repo.Save(ctx, rate))
+	}
+
+	t.Run("按时间查找生效的汇率", func(t *testing.T) {
+		found, err := repo.FindEffective(ctx, models.USD, day1.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseRate("7.1"), found.Rate)
+
+		found, err = repo.FindEffective(ctx, models.USD, day2)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseRate("7.2"), found.Rate)
+
+		found, err = repo.FindEffective(ctx, models.JPY, day2)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseRate("0.04821"), found.Rate)
+	})
+
+	t.Run("生效时间之前没有汇率", func(t *testing.T) {
+		_, err := repo.FindEffective(ctx, models.USD, day1.Add(-time.Second))
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	t.Run("同币种同生效时间重复", func(t *testing.T) {
+		err := repo.Save(ctx, &models.ExchangeRate{Currency: models.USD, Rate: models.MustParseRate("7.3"), EffectiveAt: day2})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM exchange_rates").Error; err != nil {
+		t.Fatal(err)
+	}
+}
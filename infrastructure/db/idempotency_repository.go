@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ErCodeDupEntry 唯一键冲突
+const ErCodeDupEntry uint16 = 1062
+
+type GormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyRepository(db *gorm.DB) repositories.IdempotencyRepository {
+	return &GormIdempotencyRepository{db: db}
+}
+
+func (r *GormIdempotencyRepository) FindByKey(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	if err := conn(ctx, r.db).Where("`key` = ?", key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrorNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *GormIdempotencyRepository) Save(ctx context.Context, record *models.IdempotencyRecord) error {
+	if err := conn(ctx, r.db).Create(record).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+			return repositories.ErrorDuplicate
+		}
+		return err
+	}
+	return nil
+}
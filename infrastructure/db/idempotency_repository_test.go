@@ -0,0 +1,73 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestIdempotencyDB(t *testing.T) *gorm.DB {
+	// 使用测试的数据库
+	cfg := &config.DatabaseConfig{
+		Host:      "localhost",
+		Port:      3306,
+		User:      "gouser",
+		Password:  "StrongPass123!",
+		DBName:    "go_dev_test",
+		Charset:   "utf8mb4",
+		ParseTime: true,
+	}
+
+	dbConn, err := db.NewDB(cfg)
+	assert.NoError(t, err, "数据库连接失败")
+
+	if !dbConn.Migrator().HasTable(&models.IdempotencyRecord{}) {
+		if err := dbConn.Migrator().CreateTable(&models.IdempotencyRecord{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := dbConn.Exec("DELETE FROM idempotency_records").Error; err != nil {
+		t.Fatal(err)
+	}
+	return dbConn
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestIdempotencyDB(t)
+	ctx := context.Background()
+	repo := db.NewGormIdempotencyRepository(dbConn)
+
+	record := &models.IdempotencyRecord{Key: "batch-0001", RequestHash: "hash", ResourceID: 1001}
+
+	t.Run("保存并查找幂等键", func(t *testing.T) {
+		assert.NoError(t, repo.Save(ctx, record))
+
+		found, err := repo.FindByKey(ctx, "batch-0001")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), found.ResourceID)
+		assert.Equal(t, "hash", found.RequestHash)
+	})
+
+	t.Run("重复的幂等键", func(t *testing.T) {
+		err := repo.Save(ctx, &models.IdempotencyRecord{Key: "batch-0001", RequestHash: "other", ResourceID: 1002})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	t.Run("不存在的幂等键", func(t *testing.T) {
+		_, err := repo.FindByKey(ctx, "batch-9999")
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM idempotency_records").Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErCodeLockNoWait NOWAIT 加锁时记录已被锁定（MySQL 8.0+）
+const ErCodeLockNoWait uint16 = 3572
+
+// lockForUpdate 返回带 FOR UPDATE 子句的事务句柄；ctx 不在事务中时返回 ErrorNoTransaction
+// （事务外的 FOR UPDATE 在自动提交下会立刻释放锁，没有意义）
+func lockForUpdate(ctx context.Context, mode repositories.LockMode) (*gorm.DB, error) {
+	state, ok := currentTx(ctx)
+	if !ok {
+		return nil, repositories.ErrorNoTransaction
+	}
+
+	locking := clause.Locking{Strength: clause.LockingStrengthUpdate}
+	switch mode {
+	case repositories.LockNoWait:
+		locking.Options = clause.LockingOptionsNoWait
+	case repositories.LockSkipLocked:
+		locking.Options = clause.LockingOptionsSkipLocked
+	}
+	return state.tx.WithContext(ctx).Clauses(locking), nil
+}
+
+// lockError 把加锁查询的错误转换为仓储层错误
+func lockError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repositories.ErrorNotFound
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeLockNoWait {
+		return repositories.ErrorLocked
+	}
+	return err
+}
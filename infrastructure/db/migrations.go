@@ -0,0 +1,114 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"gorm.io/gorm"
+)
+
+// ErrEmailCollision 已有用户的邮箱规范化后重复，需要人工合并后才能建立唯一索引
+var ErrEmailCollision = errors.New("存在规范化后重复的邮箱")
+
+// EmailCollision 规范化后相同的一组用户
+type EmailCollision struct {
+	CanonicalEmail string
+	UserIDs        []uint64
+}
+
+// MigrateOrderStatus 把 orders.is_valid 迁移为 orders.status：有效订单为 paid，无效订单为 cancelled
+// 可重复执行：status 列已存在时跳过添加，is_valid 列不存在时跳过回填
+// MySQL 的 DDL 会隐式提交，因此各步骤不在同一事务中；中途失败后重新执行即可
+func MigrateOrderStatus(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Order{}, "Status") {
+		if err := migrator.AddColumn(&models.Order{}, "Status"); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&models.Order{}, "idx_status") {
+		if err := migrator.CreateIndex(&models.Order{}, "idx_status"); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasColumn(&models.Order{}, "is_valid") {
+		return nil
+	}
+
+	err := db.Exec("UPDATE orders SET status = CASE WHEN is_valid = 1 THEN ? ELSE ? END",
+		models.OrderPaid, models.OrderCancelled).Error
+	if err != nil {
+		return err
+	}
+	return migrator.DropColumn(&models.Order{}, "is_valid")
+}
+
+// MigrateCanonicalEmail 为 users 添加 canonical_email 并按 canonicalize 回填，唯一索引从 email 移到 canonical_email
+// 存在规范化后重复的用户时不建立唯一索引，返回全部冲突与 ErrEmailCollision；人工处理后重新执行即可
+// 可重复执行：只回填 canonical_email 为空的用户，已存在的列与索引跳过
+func MigrateCanonicalEmail(db *gorm.DB, canonicalize func(string) string) ([]EmailCollision, error) {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.User{}, "CanonicalEmail") {
+		if err := migrator.AddColumn(&models.User{}, "CanonicalEmail"); err != nil {
+			return nil, err
+		}
+	}
+
+	var users []models.User
+	err := db.Select("id", "email").Where("canonical_email = ''").
+		FindInBatches(&users, 500, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				err := db.Model(&models.User{}).Where("id = ?", user.ID).
+					Update("canonical_email", canonicalize(user.Email)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	collisions, err := findEmailCollisions(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(collisions) > 0 {
+		return collisions, ErrEmailCollision
+	}
+
+	if !migrator.HasIndex(&models.User{}, "idx_users_canonical_email") {
+		if err := migrator.CreateIndex(&models.User{}, "idx_users_canonical_email"); err != nil {
+			return nil, err
+		}
+	}
+	if migrator.HasIndex(&models.User{}, "idx_users_email") {
+		if err := migrator.DropIndex(&models.User{}, "idx_users_email"); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// findEmailCollisions 按规范化邮箱分组，返回包含多个用户的分组
+func findEmailCollisions(db *gorm.DB) ([]EmailCollision, error) {
+	var duplicated []string
+	err := db.Model(&models.User{}).Group("canonical_email").Having("COUNT(*) > 1").
+		Order("canonical_email").Pluck("canonical_email", &duplicated).Error
+	if err != nil {
+		return nil, err
+	}
+
+	collisions := make([]EmailCollision, 0, len(duplicated))
+	for _, canonical := range duplicated {
+		collision := EmailCollision{CanonicalEmail: canonical}
+		err := db.Model(&models.User{}).Where("canonical_email = ?", canonical).
+			Order("id").Pluck("id", &collision.UserIDs).Error
+		if err != nil {
+			return nil, err
+		}
+		collisions = append(collisions, collision)
+	}
+	return collisions, nil
+}
@@ -0,0 +1,94 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateOrderStatus(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOrderRepository(dbConn)
+
+	// 还原为迁移前的表结构：只有 is_valid，没有 status
+	migrator := dbConn.Migrator()
+	if migrator.HasColumn(&models.Order{}, "Status") {
+		assert.NoError(t, migrator.DropColumn(&models.Order{}, "Status"))
+	}
+	if !migrator.HasColumn(&models.Order{}, "is_valid") {
+		assert.NoError(t, dbConn.Exec("ALTER TABLE orders ADD COLUMN is_valid tinyint(1) NOT NULL DEFAULT 1").Error)
+	}
+	assert.NoError(t, dbConn.Exec(
+		"INSERT INTO orders (order_id, user_id, amount, is_valid) VALUES (1001, 10001, 100, 1), (1002, 10001, 200, 0)").Error)
+
+	t.Run("有效订单迁移为paid，无效订单迁移为cancelled", func(t *testing.T) {
+		assert.NoError(t, db.MigrateOrderStatus(dbConn))
+		assert.False(t, migrator.HasColumn(&models.Order{}, "is_valid"))
+
+		valid, err := repo.FindByID(ctx, 1001)
+		assert.NoError(t, err)
+		assert.Equal(t, models.OrderPaid, valid.Status)
+
+		invalid, err := repo.FindByID(ctx, 1002)
+		assert.NoError(t, err)
+		assert.Equal(t, models.OrderCancelled, invalid.Status)
+	})
+
+	t.Run("重复执行", func(t *testing.T) {
+		assert.NoError(t, db.MigrateOrderStatus(dbConn))
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateCanonicalEmail(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	repo := db.NewGormUserRepository(dbConn)
+	ctx := context.Background()
+	canonicalize := models.DefaultEmailPolicy().Canonicalize
+
+	// 还原为迁移前的表结构：没有 canonical_email
+	// 旧的 email 唯一索引在默认排序规则下不区分大小写，先去掉以便构造冲突数据
+	migrator := dbConn.Migrator()
+	if migrator.HasColumn(&models.User{}, "CanonicalEmail") {
+		assert.NoError(t, migrator.DropColumn(&models.User{}, "CanonicalEmail"))
+	}
+	if migrator.HasIndex(&models.User{}, "idx_users_email") {
+		assert.NoError(t, migrator.DropIndex(&models.User{}, "idx_users_email"))
+	}
+	assert.NoError(t, dbConn.Exec(
+		"INSERT INTO users (id, name, email) VALUES (1, 'a', 'A@qq.com'), (2, 'b', 'a@qq.com'), (3, 'c', 'c@qq.com')").Error)
+
+	t.Run("存在冲突时返回全部冲突且不建立唯一索引", func(t *testing.T) {
+		collisions, err := db.MigrateCanonicalEmail(dbConn, canonicalize)
+		assert.ErrorIs(t, err, db.ErrEmailCollision)
+		assert.Equal(t, []db.EmailCollision{{CanonicalEmail: "a@qq.com", UserIDs: []uint64{1, 2}}}, collisions)
+		assert.False(t, migrator.HasIndex(&models.User{}, "idx_users_canonical_email"))
+	})
+
+	t.Run("处理冲突后重新执行", func(t *testing.T) {
+		assert.NoError(t, dbConn.Exec("DELETE FROM users WHERE id = 2").Error)
+		collisions, err := db.MigrateCanonicalEmail(dbConn, canonicalize)
+		assert.NoError(t, err)
+		assert.Empty(t, collisions)
+		assert.True(t, migrator.HasIndex(&models.User{}, "idx_users_canonical_email"))
+
+		found, err := repo.FindByEmail(ctx, "a@qq.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "A@qq.com", found.Email)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,12 +1,15 @@
 package db
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormOrderRepository struct {
@@ -17,9 +20,14 @@ func NewGormOrderRepository(db *gorm.DB) repositories.OrderRepository {
 	return &GormOrderRepository{db: db}
 }
 
-func (r *GormOrderRepository) FindByID(orderID uint64) (*models.Order, error) {
+// orderedItems 查询订单时按写入顺序一并加载订单明细
+func orderedItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
+
+func (r *GormOrderRepository) FindByID(ctx context.Context, orderID uint64) (*models.Order, error) {
 	var order models.Order
-	if err := r.db.First(&order, orderID).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Items", orderedItems).First(&order, orderID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repositories.ErrorNotFound
 		}
@@ -28,25 +36,78 @@ func (r *GormOrderRepository) FindByID(orderID uint64) (*models.Order, error) {
 	return &order, nil
 }
 
-func (r *GormOrderRepository) Save(order *models.Order) (uint64, error) {
-	if err := r.db.Save(order).Error; err != nil {
+func (r *GormOrderRepository) FindByIDForUpdate(ctx context.Context, orderID uint64, mode repositories.LockMode) (*models.Order, error) {
+	tx, err := lockForUpdate(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+	var order models.Order
+	if err := tx.Preload("Items", orderedItems).First(&order, orderID).Error; err != nil {
+		return nil, lockError(err)
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) FindByUserID(ctx context.Context, userID uint64) ([]*models.Order, error) {
+	orders := []*models.Order{}
+	err := conn(ctx, r.db).Preload("Items", orderedItems).Where("user_id = ?", userID).Order("order_id").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *GormOrderRepository) FindByUserIDSince(ctx context.Context, userID uint64, since time.Time) ([]*models.Order, error) {
+	orders := []*models.Order{}
+	err := conn(ctx, r.db).Where("user_id = ? AND created_at >= ?", userID, since).Order("order_id").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// Save 只写入订单本身，订单明细通过 OrderItemRepository 写入
+func (r *GormOrderRepository) Save(ctx context.Context, order *models.Order) (uint64, error) {
+	if err := conn(ctx, r.db).Omit(clause.Associations).Save(order).Error; err != nil {
 		return uint64(0), err
 	}
 	return order.OrderID, nil
 }
 
-func (r *GormOrderRepository) UpdateValidity(orderID uint64, isValid bool) (int8, error) {
-	var valid_num int8
-	if isValid {
-		valid_num = 1
-	} else {
-		valid_num = 0
-	}
-	result := r.db.Model(&models.Order{}).
-		Where("order_id = ?", orderID).
-		Update("is_valid", valid_num)
+func (r *GormOrderRepository) UpdateStatus(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error) {
+	return r.updateFrom(ctx, order, from, map[string]interface{}{
+		"status": order.Status,
+	})
+}
+
+func (r *GormOrderRepository) UpdateRefund(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error) {
+	return r.updateFrom(ctx, order, from, map[string]interface{}{
+		"status":               order.Status,
+		"refunded_amount":      order.RefundedAmount,
+		"refunded_base_amount": order.RefundedBaseAmount,
+	})
+}
+
+// updateFrom 仅当数据库中的状态等于 from 且版本号等于 order.Version 时更新 values，并把版本号加1
+func (r *GormOrderRepository) updateFrom(ctx context.Context, order *models.Order, from models.OrderStatus, values map[string]interface{}) (int8, error) {
+	values["version"] = gorm.Expr("version + 1")
+	result := conn(ctx, r.db).Model(&models.Order{}).
+		Where("order_id = ? AND status = ? AND version = ?", order.OrderID, from, order.Version).
+		Updates(values)
 	if result.Error != nil {
 		return int8(0), result.Error
 	}
+	if result.RowsAffected == 0 {
+		// 订单存在但未更新，说明状态或版本已被其他事务修改
+		found, err := exists(conn(ctx, r.db), &models.Order{}, "order_id = ?", order.OrderID)
+		if err != nil {
+			return int8(0), err
+		}
+		if found {
+			return int8(0), repositories.ErrorVersionConflict
+		}
+		return int8(0), nil
+	}
+	order.Version++
 	return int8(result.RowsAffected), nil
 }
@@ -1,7 +1,9 @@
 package db_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
@@ -54,44 +56,41 @@ func setupTestOrderDB(t *testing.T) *gorm.DB {
 func TestOrderRepository_FindByID(t *testing.T) {
 	// 连接db
 	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
 	repo := db.NewGormOrderRepository(dbConn)
 	user_repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("成功查找到订单ID", func(t *testing.T) {
 		// 由于外键约束，先把用户存进去
-		user := &models.User{
-			ID:               uint64(10001),
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 2000,
-		}
-		_, err := user_repo.Save(user)
+		user := newTestUser(t, 10001, "test", "test@example.com")
+		user.TotalConsumption = models.MustParseMoney("2000")
+		_, err := user_repo.Save(ctx, user)
 		assert.NoError(t, err)
 
 		order := &models.Order{
 			OrderID: uint64(2025052110001),
 			UserID:  uint64(10001),
-			Amount:  float64(1000),
-			IsValid: true,
+			Amount:  models.MustParseMoney("1000"),
+			Status:  models.OrderPending,
 		}
 
 		// 执行保存
-		_, err = repo.Save(order)
+		_, err = repo.Save(ctx, order)
 		assert.NoError(t, err)
 
 		// 查找
-		found_order, err := repo.FindByID(order.OrderID)
+		found_order, err := repo.FindByID(ctx, order.OrderID)
 		// 验证数据
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(2025052110001), found_order.OrderID)
 		assert.Equal(t, uint64(10001), found_order.UserID)
-		assert.Equal(t, float64(1000), found_order.Amount)
-		assert.Equal(t, true, found_order.IsValid)
+		assert.Equal(t, models.MustParseMoney("1000"), found_order.Amount)
+		assert.Equal(t, models.OrderPending, found_order.Status)
 	})
 
 	t.Run("无法找到用户ID", func(t *testing.T) {
 		// 查找
-		_, err := repo.FindByID(uint64(1002))
+		_, err := repo.FindByID(ctx, uint64(1002))
 		assert.ErrorIs(t, err, repositories.ErrorNotFound)
 	})
 
@@ -104,42 +103,118 @@ func TestOrderRepository_FindByID(t *testing.T) {
 	}
 }
 
+func TestOrderRepository_FindByUserID(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOrderRepository(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+
+	// 由于外键约束，先把用户存进去
+	_, err := user_repo.Save(ctx, newTestUser(t, 10001, "test", "test@example.com"))
+	assert.NoError(t, err)
+	for _, id := range []uint64{2025052110002, 2025052110001} {
+		_, err := repo.Save(ctx, &models.Order{OrderID: id, UserID: 10001, Amount: models.MustParseMoney("100"), Status: models.OrderPaid})
+		assert.NoError(t, err)
+	}
+
+	t.Run("按订单ID升序返回用户的订单", func(t *testing.T) {
+		orders, err := repo.FindByUserID(ctx, 10001)
+		assert.NoError(t, err)
+		if assert.Len(t, orders, 2) {
+			assert.Equal(t, uint64(2025052110001), orders[0].OrderID)
+			assert.Equal(t, uint64(2025052110002), orders[1].OrderID)
+		}
+	})
+
+	t.Run("没有订单时返回空切片", func(t *testing.T) {
+		orders, err := repo.FindByUserID(ctx, 10002)
+		assert.NoError(t, err)
+		assert.Empty(t, orders)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderRepository_FindByUserIDSince(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOrderRepository(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+
+	// 由于外键约束，先把用户存进去
+	_, err := user_repo.Save(ctx, newTestUser(t, 10001, "test", "test@example.com"))
+	assert.NoError(t, err)
+	now := time.Now()
+	for i, createdAt := range []time.Time{now.Add(-40 * 24 * time.Hour), now.Add(-time.Hour), now} {
+		_, err := repo.Save(ctx, &models.Order{
+			OrderID:   uint64(2025052110001 + i),
+			UserID:    10001,
+			Amount:    models.MustParseMoney("100"),
+			Status:    models.OrderPaid,
+			CreatedAt: createdAt,
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("只返回统计区间内的订单", func(t *testing.T) {
+		orders, err := repo.FindByUserIDSince(ctx, 10001, now.Add(-models.SpendingWindow))
+		assert.NoError(t, err)
+		if assert.Len(t, orders, 2) {
+			assert.Equal(t, uint64(2025052110002), orders[0].OrderID)
+			assert.Equal(t, uint64(2025052110003), orders[1].OrderID)
+		}
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestOrderRepository_Save(t *testing.T) {
 	// 连接db
 	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
 	repo := db.NewGormOrderRepository(dbConn)
 	user_repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("成功保存用户", func(t *testing.T) {
 		// 由于外键约束，先把用户存进去
-		user := &models.User{
-			ID:               uint64(10001),
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 2000,
-		}
-		_, err := user_repo.Save(user)
+		user := newTestUser(t, 10001, "test", "test@example.com")
+		user.TotalConsumption = models.MustParseMoney("2000")
+		_, err := user_repo.Save(ctx, user)
 		assert.NoError(t, err)
 
 		order := &models.Order{
 			OrderID: uint64(2025052110001),
 			UserID:  uint64(10001),
-			Amount:  float64(1000),
-			IsValid: true,
+			Amount:  models.MustParseMoney("1000"),
+			Status:  models.OrderPending,
 		}
 
 		// 执行保存
-		_, err = repo.Save(order)
+		_, err = repo.Save(ctx, order)
 		assert.NoError(t, err)
 
 		// 查找
-		found_order, err := repo.FindByID(order.OrderID)
+		found_order, err := repo.FindByID(ctx, order.OrderID)
 		// 验证数据
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(2025052110001), found_order.OrderID)
 		assert.Equal(t, uint64(10001), found_order.UserID)
-		assert.Equal(t, float64(1000), found_order.Amount)
-		assert.Equal(t, true, found_order.IsValid)
+		assert.Equal(t, models.MustParseMoney("1000"), found_order.Amount)
+		assert.Equal(t, models.OrderPending, found_order.Status)
 	})
 
 	// 清空环境
@@ -151,63 +226,137 @@ func TestOrderRepository_Save(t *testing.T) {
 	}
 }
 
-func TestOrderRepository_UpdateValidity(t *testing.T) {
+func TestOrderRepository_UpdateStatus(t *testing.T) {
 	// 连接db
 	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
 	repo := db.NewGormOrderRepository(dbConn)
 	user_repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("更新订单失效成功", func(t *testing.T) {
 		// 由于外键约束，先把用户存进去
-		user := &models.User{
-			ID:               uint64(10001),
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 2000,
-		}
-		_, err := user_repo.Save(user)
+		user := newTestUser(t, 10001, "test", "test@example.com")
+		user.TotalConsumption = models.MustParseMoney("2000")
+		_, err := user_repo.Save(ctx, user)
 		assert.NoError(t, err)
 
 		order := &models.Order{
 			OrderID: uint64(2025052110001),
 			UserID:  uint64(10001),
-			Amount:  float64(1000),
-			IsValid: true,
+			Amount:  models.MustParseMoney("1000"),
+			Status:  models.OrderPending,
 		}
-		_, err = repo.Save(order)
+		_, err = repo.Save(ctx, order)
 		assert.NoError(t, err)
 
 		// 执行更新
-		rows, err := repo.UpdateValidity(order.OrderID, false)
+		order.Status = models.OrderCancelled
+		rows, err := repo.UpdateStatus(ctx, order, models.OrderPending)
 		assert.NoError(t, err)
-		assert.Equal(t, int8(1), rows) // 验证影响行数
+		assert.Equal(t, int8(1), rows)            // 验证影响行数
+		assert.Equal(t, uint64(1), order.Version) // 版本号加1
 
 		// 验证数据
-		found_order, err := repo.FindByID(order.OrderID)
+		found_order, err := repo.FindByID(ctx, order.OrderID)
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(2025052110001), found_order.OrderID)
 		assert.Equal(t, uint64(10001), found_order.UserID)
-		assert.Equal(t, float64(1000), found_order.Amount)
-		assert.Equal(t, false, found_order.IsValid)
+		assert.Equal(t, models.MustParseMoney("1000"), found_order.Amount)
+		assert.Equal(t, models.OrderCancelled, found_order.Status)
+		assert.Equal(t, uint64(1), found_order.Version)
 	})
 
-	t.Run("更新订单已失效或是不存在的订单", func(t *testing.T) {
+	t.Run("使用过期版本更新时返回冲突", func(t *testing.T) {
 		order := &models.Order{
 			OrderID: uint64(2025052110001), // 上一个测试样例存储的
+			Status:  models.OrderCancelled,
+			Version: 0, // 已被上一个测试样例更新为1
 		}
 
 		// 执行更新
-		rows, err := repo.UpdateValidity(order.OrderID, false)
-		assert.NoError(t, err)
+		rows, err := repo.UpdateStatus(ctx, order, models.OrderPending)
+		assert.ErrorIs(t, err, repositories.ErrorVersionConflict)
 		assert.Equal(t, int8(0), rows) // 验证影响行数
 
 		// 验证数据
-		found_order, err := repo.FindByID(order.OrderID)
+		found_order, err := repo.FindByID(ctx, order.OrderID)
 		assert.NoError(t, err)
 		assert.Equal(t, uint64(2025052110001), found_order.OrderID)
 		assert.Equal(t, uint64(10001), found_order.UserID)
-		assert.Equal(t, float64(1000), found_order.Amount)
-		assert.Equal(t, false, found_order.IsValid)
+		assert.Equal(t, models.MustParseMoney("1000"), found_order.Amount)
+		assert.Equal(t, models.OrderCancelled, found_order.Status)
+	})
+
+	t.Run("数据库中的状态与期望的原状态不一致时返回冲突", func(t *testing.T) {
+		order := &models.Order{
+			OrderID: uint64(2025052110001),
+			Status:  models.OrderPaid,
+			Version: 1,
+		}
+
+		// 数据库中已是 cancelled
+		rows, err := repo.UpdateStatus(ctx, order, models.OrderPending)
+		assert.ErrorIs(t, err, repositories.ErrorVersionConflict)
+		assert.Equal(t, int8(0), rows)
+		assert.Equal(t, uint64(1), order.Version)
+	})
+
+	t.Run("更新不存在的订单", func(t *testing.T) {
+		rows, err := repo.UpdateStatus(ctx, &models.Order{OrderID: uint64(1002), Status: models.OrderPaid}, models.OrderPending)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(0), rows) // 验证影响行数
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderRepository_FindByIDForUpdate(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOrderDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOrderRepository(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+	tm := db.NewTransactionManager(dbConn)
+
+	// 由于外键约束，先把用户存进去
+	user := newTestUser(t, 10001, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("2000")
+	_, err := user_repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	order := &models.Order{
+		OrderID: uint64(2025052110001),
+		UserID:  uint64(10001),
+		Amount:  models.MustParseMoney("1000"),
+		Status:  models.OrderPending,
+	}
+	_, err = repo.Save(ctx, order)
+	assert.NoError(t, err)
+
+	t.Run("事务外调用返回错误", func(t *testing.T) {
+		_, err := repo.FindByIDForUpdate(ctx, order.OrderID, repositories.LockWait)
+		assert.ErrorIs(t, err, repositories.ErrorNoTransaction)
+	})
+
+	t.Run("事务内加锁后其他事务NOWAIT失败", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			found_order, err := repo.FindByIDForUpdate(txCtx, order.OrderID, repositories.LockWait)
+			assert.NoError(t, err)
+			assert.Equal(t, models.MustParseMoney("1000"), found_order.Amount)
+
+			return tm.Transaction(ctx, func(otherCtx context.Context) error {
+				_, err := repo.FindByIDForUpdate(otherCtx, order.OrderID, repositories.LockNoWait)
+				assert.ErrorIs(t, err, repositories.ErrorLocked)
+				return nil
+			})
+		})
+		assert.NoError(t, err)
 	})
 
 	// 清空环境
@@ -0,0 +1,70 @@
+package db_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// 并发下单不能丢失消费总额的更新
+func TestOrderService_ConcurrentCreateOrder(t *testing.T) {
+	// 连接db
+	dbConn := setupTestTxDB(t)
+	ctx := context.Background()
+
+	// 限制连接数，避免超过MySQL的max_connections
+	sqlDB, err := dbConn.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(20)
+
+	user_repo := db.NewGormUserRepository(dbConn)
+	order_repo := db.NewGormOrderRepository(dbConn)
+	service := services.NewOrderService(user_repo, order_repo, db.NewTransactionManager(dbConn))
+
+	user := newTestUser(t, 0, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("100")
+	userID, err := user_repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	const orderNum = 300
+	amount := models.MustParseMoney("10.01")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, orderNum)
+	for i := 0; i < orderNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.CreateOrder(ctx, services.CreateOrderCommand{UserID: userID, Amount: amount})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// 验证最终消费总额与订单数量
+	foundUser, err := user_repo.FindByID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MoneyFromCents(100*100+orderNum*amount.Cents()), foundUser.TotalConsumption)
+
+	var count int64
+	assert.NoError(t, dbConn.Model(&models.Order{}).Where("user_id = ?", userID).Count(&count).Error)
+	assert.Equal(t, int64(orderNum), count)
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
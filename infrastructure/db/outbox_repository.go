@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// maxLastErrorLen last_error 列的长度（字符数）
+const maxLastErrorLen = 500
+
+type GormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOutboxRepository(db *gorm.DB) repositories.OutboxRepository {
+	return &GormOutboxRepository{db: db}
+}
+
+func (r *GormOutboxRepository) Save(ctx context.Context, msg *models.OutboxMessage) (uint64, error) {
+	if err := conn(ctx, r.db).Create(msg).Error; err != nil {
+		return uint64(0), err
+	}
+	return msg.ID, nil
+}
+
+func (r *GormOutboxRepository) FindPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	var msgs []*models.OutboxMessage
+	err := conn(ctx, r.db).
+		Where("published_at IS NULL AND dead_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", now).
+		// 同一聚合更早的消息还在等待重试
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_messages AS earlier
+			WHERE earlier.aggregate_type = outbox_messages.aggregate_type
+			AND earlier.aggregate_id = outbox_messages.aggregate_id
+			AND earlier.id < outbox_messages.id
+			AND earlier.published_at IS NULL AND earlier.dead_at IS NULL AND earlier.next_attempt_at > ?)`, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (r *GormOutboxRepository) MarkPublished(ctx context.Context, id uint64, publishedAt time.Time) error {
+	result := conn(ctx, r.db).Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Update("published_at", publishedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *GormOutboxRepository) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	return r.markFailure(ctx, id, reason, "next_attempt_at", nextAttemptAt)
+}
+
+func (r *GormOutboxRepository) MarkDead(ctx context.Context, id uint64, reason string, deadAt time.Time) error {
+	return r.markFailure(ctx, id, reason, "dead_at", deadAt)
+}
+
+// markFailure 失败次数加1、记录原因，并写入 column（下次重试时间或停放时间）
+func (r *GormOutboxRepository) markFailure(ctx context.Context, id uint64, reason string, column string, at time.Time) error {
+	if runes := []rune(reason); len(runes) > maxLastErrorLen {
+		reason = string(runes[:maxLastErrorLen])
+	}
+	result := conn(ctx, r.db).Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": reason,
+			column:       at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *GormOutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&models.OutboxMessage{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
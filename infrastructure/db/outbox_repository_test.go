@@ -0,0 +1,123 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestOutboxDB(t *testing.T) *gorm.DB {
+	// 使用测试的数据库
+	cfg := &config.DatabaseConfig{
+		Host:      "localhost",
+		Port:      3306,
+		User:      "gouser",
+		Password:  "StrongPass123!",
+		DBName:    "go_dev_test",
+		Charset:   "utf8mb4",
+		ParseTime: true,
+	}
+
+	dbConn, err := db.NewDB(cfg)
+	assert.NoError(t, err, "数据库连接失败")
+
+	if !dbConn.Migrator().HasTable(&models.OutboxMessage{}) {
+		if err := dbConn.Migrator().CreateTable(&models.OutboxMessage{}); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := dbConn.AutoMigrate(&models.OutboxMessage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dbConn.Exec("DELETE FROM outbox_messages").Error; err != nil {
+		t.Fatal(err)
+	}
+	return dbConn
+}
+
+func TestOutboxRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOutboxDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOutboxRepository(dbConn)
+
+	var ids []uint64
+	for i := uint64(1); i <= 3; i++ {
+		msg, err := models.NewOutboxMessage(models.AggregateOrder, 1000+i, models.EventOrderCreated,
+			models.OrderEvent{OrderID: 1000 + i, UserID: 1, Amount: models.MustParseMoney("100"),
+				Currency: models.CNY, BaseAmount: models.MustParseMoney("100"), Status: models.OrderPending})
+		assert.NoError(t, err)
+		id, err := repo.Save(ctx, msg)
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	t.Run("按ID顺序读取待发布消息", func(t *testing.T) {
+		msgs, err := repo.FindPending(ctx, time.Now(), 2)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 2)
+		assert.Equal(t, ids[0], msgs[0].ID)
+		assert.Equal(t, ids[1], msgs[1].ID)
+		assert.JSONEq(t, `{"order_id":1001,"user_id":1,"amount":100,"currency":"CNY","base_amount":100,"status":"pending"}`, msgs[0].Payload)
+	})
+
+	t.Run("标记发布失败与已发布", func(t *testing.T) {
+		assert.NoError(t, repo.MarkFailed(ctx, ids[0], "broker down", time.Now().Add(-time.Second)))
+		assert.NoError(t, repo.MarkPublished(ctx, ids[1], time.Now().Add(-2*time.Hour)))
+		assert.ErrorIs(t, repo.MarkPublished(ctx, ids[2]+100, time.Now()), repositories.ErrorNotFound)
+
+		msgs, err := repo.FindPending(ctx, time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 2)
+		assert.Equal(t, ids[0], msgs[0].ID)
+		assert.Equal(t, 1, msgs[0].Attempts)
+		assert.Equal(t, "broker down", msgs[0].LastError)
+		assert.Equal(t, ids[2], msgs[1].ID)
+	})
+
+	t.Run("等待重试与停放的消息不阻塞其他聚合", func(t *testing.T) {
+		// ids[0]（订单1001）等待重试，其后同一订单的消息不返回
+		later, err := models.NewOutboxMessage(models.AggregateOrder, 1001, models.EventOrderInvalidated, models.OrderEvent{OrderID: 1001})
+		assert.NoError(t, err)
+		laterID, err := repo.Save(ctx, later)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.MarkFailed(ctx, ids[0], "broker down", time.Now().Add(time.Hour)))
+		// ids[2]（订单1003）无法发布，已停放
+		assert.NoError(t, repo.MarkDead(ctx, ids[2], "invalid payload", time.Now()))
+		// 其他聚合的消息照常发布
+		other, err := models.NewOutboxMessage(models.AggregateUser, 1, models.EventConsumptionChanged, models.ConsumptionChangedEvent{UserID: 1})
+		assert.NoError(t, err)
+		otherID, err := repo.Save(ctx, other)
+		assert.NoError(t, err)
+
+		msgs, err := repo.FindPending(ctx, time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 1)
+		assert.Equal(t, otherID, msgs[0].ID)
+
+		// 到达重试时间后按顺序返回
+		msgs, err = repo.FindPending(ctx, time.Now().Add(2*time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Len(t, msgs, 3)
+		assert.Equal(t, []uint64{ids[0], laterID, otherID}, []uint64{msgs[0].ID, msgs[1].ID, msgs[2].ID})
+		assert.Equal(t, 2, msgs[0].Attempts)
+	})
+
+	t.Run("清理已发布消息", func(t *testing.T) {
+		deleted, err := repo.DeletePublishedBefore(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM outbox_messages").Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type GormProductRepository struct {
+	db *gorm.DB
+}
+
+func NewGormProductRepository(db *gorm.DB) repositories.ProductRepository {
+	return &GormProductRepository{db: db}
+}
+
+func (r *GormProductRepository) FindByID(ctx context.Context, id uint64) (*models.Product, error) {
+	var product models.Product
+	if err := conn(ctx, r.db).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrorNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *GormProductRepository) FindBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	var product models.Product
+	if err := conn(ctx, r.db).Where("sku = ?", sku).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repositories.ErrorNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *GormProductRepository) Save(ctx context.Context, product *models.Product) (uint64, error) {
+	if err := conn(ctx, r.db).Save(product).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+			return uint64(0), repositories.ErrorDuplicate
+		}
+		return uint64(0), err
+	}
+	return product.ID, nil
+}
+
+func (r *GormProductRepository) DecrementStock(ctx context.Context, productID uint64, quantity int) error {
+	result := conn(ctx, r.db).Model(&models.Product{}).
+		Where("id = ? AND stock >= ?", productID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 未更新：商品不存在或库存不足
+		product, err := r.FindByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		return &models.OutOfStockError{SKU: product.SKU, Requested: quantity, Available: product.Stock}
+	}
+	return nil
+}
+
+func (r *GormProductRepository) IncrementStock(ctx context.Context, productID uint64, quantity int) error {
+	result := conn(ctx, r.db).Model(&models.Product{}).
+		Where("id = ?", productID).
+		Update("stock", gorm.Expr("stock + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		found, err := exists(conn(ctx, r.db), &models.Product{}, "id = ?", productID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return repositories.ErrorNotFound
+		}
+	}
+	return nil
+}
+
+func (r *GormProductRepository) FindLowStock(ctx context.Context, threshold int) ([]*models.Product, error) {
+	products := []*models.Product{}
+	if err := conn(ctx, r.db).Where("stock <= ?", threshold).Order("stock, id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+type GormOrderItemRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOrderItemRepository(db *gorm.DB) repositories.OrderItemRepository {
+	return &GormOrderItemRepository{db: db}
+}
+
+func (r *GormOrderItemRepository) Save(ctx context.Context, items []models.OrderItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&items).Error
+}
+
+func (r *GormOrderItemRepository) FindByOrderID(ctx context.Context, orderID uint64) ([]models.OrderItem, error) {
+	items := []models.OrderItem{}
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
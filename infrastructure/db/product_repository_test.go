@@ -0,0 +1,178 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestProductDB(t *testing.T) *gorm.DB {
+	dbConn := setupTestOrderDB(t)
+	if err := dbConn.AutoMigrate(&models.Product{}, &models.OrderItem{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"order_items", "products"} {
+		if err := dbConn.Exec("DELETE FROM " + table).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dbConn
+}
+
+func TestProductRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestProductDB(t)
+	ctx := context.Background()
+	repo := db.NewGormProductRepository(dbConn)
+
+	product := &models.Product{SKU: "SKU-001", Name: "水杯", UnitPrice: models.MustParseMoney("19.9"), Currency: models.CNY}
+
+	t.Run("保存并按ID与SKU查找", func(t *testing.T) {
+		id, err := repo.Save(ctx, product)
+		assert.NoError(t, err)
+
+		found, err := repo.FindByID(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "SKU-001", found.SKU)
+		assert.Equal(t, models.MustParseMoney("19.9"), found.UnitPrice)
+
+		found, err = repo.FindBySKU(ctx, "SKU-001")
+		assert.NoError(t, err)
+		assert.Equal(t, id, found.ID)
+	})
+
+	t.Run("SKU重复", func(t *testing.T) {
+		_, err := repo.Save(ctx, &models.Product{SKU: "SKU-001", Name: "另一个水杯", UnitPrice: models.MustParseMoney("9.9")})
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	t.Run("商品不存在", func(t *testing.T) {
+		_, err := repo.FindBySKU(ctx, "SKU-404")
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM products").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProductRepository_Stock(t *testing.T) {
+	// 连接db
+	dbConn := setupTestProductDB(t)
+	ctx := context.Background()
+	repo := db.NewGormProductRepository(dbConn)
+
+	cup := &models.Product{SKU: "SKU-001", Name: "水杯", UnitPrice: models.MustParseMoney("19.9"), Stock: 5}
+	pen := &models.Product{SKU: "SKU-002", Name: "钢笔", UnitPrice: models.MustParseMoney("35"), Stock: 1}
+	for _, p := range []*models.Product{cup, pen} {
+		_, err := repo.Save(ctx, p)
+		assert.NoError(t, err)
+	}
+
+	t.Run("扣减库存", func(t *testing.T) {
+		assert.NoError(t, repo.DecrementStock(ctx, cup.ID, 3))
+		found, err := repo.FindByID(ctx, cup.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, found.Stock)
+	})
+
+	t.Run("库存不足时不扣减", func(t *testing.T) {
+		err := repo.DecrementStock(ctx, cup.ID, 3)
+		var outOfStock *models.OutOfStockError
+		if assert.ErrorAs(t, err, &outOfStock) {
+			assert.Equal(t, "SKU-001", outOfStock.SKU)
+			assert.Equal(t, 2, outOfStock.Available)
+		}
+		found, err := repo.FindByID(ctx, cup.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, found.Stock)
+	})
+
+	t.Run("补货", func(t *testing.T) {
+		assert.NoError(t, repo.IncrementStock(ctx, pen.ID, 4))
+		found, err := repo.FindByID(ctx, pen.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, found.Stock)
+	})
+
+	t.Run("商品不存在", func(t *testing.T) {
+		assert.ErrorIs(t, repo.DecrementStock(ctx, 404, 1), repositories.ErrorNotFound)
+		assert.ErrorIs(t, repo.IncrementStock(ctx, 404, 1), repositories.ErrorNotFound)
+	})
+
+	t.Run("低库存商品按库存升序", func(t *testing.T) {
+		products, err := repo.FindLowStock(ctx, 5)
+		assert.NoError(t, err)
+		if assert.Len(t, products, 2) {
+			assert.Equal(t, "SKU-001", products[0].SKU)
+			assert.Equal(t, "SKU-002", products[1].SKU)
+		}
+
+		products, err = repo.FindLowStock(ctx, 2)
+		assert.NoError(t, err)
+		assert.Len(t, products, 1)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM products").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderItemRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestProductDB(t)
+	ctx := context.Background()
+	repo := db.NewGormOrderItemRepository(dbConn)
+	order_repo := db.NewGormOrderRepository(dbConn)
+	tm := db.NewTransactionManager(dbConn)
+
+	cup := &models.Product{ID: 1, SKU: "SKU-001", Name: "水杯", UnitPrice: models.MustParseMoney("19.9"), Currency: models.CNY}
+	pen := &models.Product{ID: 2, SKU: "SKU-002", Name: "钢笔", UnitPrice: models.MustParseMoney("35"), Currency: models.CNY}
+	first, _ := models.NewOrderItem(cup, 3)
+	second, _ := models.NewOrderItem(pen, 2)
+	order, err := (&models.User{ID: 10001}).CreateOrderFromItems(10001, []models.OrderItem{first, second}, models.BaseExchangeRate())
+	assert.NoError(t, err)
+
+	t.Run("订单与明细在同一事务中写入，查询订单时返回明细", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			if order.OrderID, err = order_repo.Save(txCtx, order); err != nil {
+				return err
+			}
+			for i := range order.Items {
+				order.Items[i].OrderID = order.OrderID
+			}
+			return repo.Save(txCtx, order.Items)
+		})
+		assert.NoError(t, err)
+		assert.NotZero(t, order.Items[0].ID)
+
+		found, err := order_repo.FindByID(ctx, order.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("129.7"), found.Amount)
+		if assert.Len(t, found.Items, 2) {
+			assert.Equal(t, "SKU-001", found.Items[0].SKU)
+			assert.Equal(t, 3, found.Items[0].Quantity)
+			assert.Equal(t, models.MustParseMoney("59.7"), found.Items[0].Subtotal)
+			assert.Equal(t, "SKU-002", found.Items[1].SKU)
+		}
+
+		items, err := repo.FindByOrderID(ctx, order.OrderID)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM order_items").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type GormRefundRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRefundRepository(db *gorm.DB) repositories.RefundRepository {
+	return &GormRefundRepository{db: db}
+}
+
+func (r *GormRefundRepository) Save(ctx context.Context, refund *models.Refund) (uint64, error) {
+	if err := conn(ctx, r.db).Create(refund).Error; err != nil {
+		return uint64(0), err
+	}
+	return refund.ID, nil
+}
+
+func (r *GormRefundRepository) FindByOrderID(ctx context.Context, orderID uint64) ([]*models.Refund, error) {
+	refunds := []*models.Refund{}
+	if err := conn(ctx, r.db).Where("order_id = ?", orderID).Order("id").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
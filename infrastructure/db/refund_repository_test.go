@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestOrderDB(t)
+	if err := dbConn.AutoMigrate(&models.Refund{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM refunds").Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repo := db.NewGormRefundRepository(dbConn)
+	order_repo := db.NewGormOrderRepository(dbConn)
+
+	order := &models.Order{
+		UserID:       uint64(10001),
+		Amount:       models.MustParseMoney("300"),
+		ExchangeRate: models.RateOne,
+		BaseAmount:   models.MustParseMoney("300"),
+		Status:       models.OrderPaid,
+	}
+	_, err := order_repo.Save(ctx, order)
+	assert.NoError(t, err)
+
+	t.Run("写入累计退款金额", func(t *testing.T) {
+		refund, err := order.Refund(models.MustParseMoney("100"), "商品破损")
+		assert.NoError(t, err)
+		rows, err := order_repo.UpdateRefund(ctx, order, models.OrderPaid)
+		assert.NoError(t, err)
+		assert.Equal(t, int8(1), rows)
+		_, err = repo.Save(ctx, refund)
+		assert.NoError(t, err)
+
+		found, err := order_repo.FindByID(ctx, order.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("100"), found.RefundedAmount)
+		assert.Equal(t, models.MustParseMoney("100"), found.RefundedBaseAmount)
+		assert.Equal(t, models.OrderPaid, found.Status)
+	})
+
+	t.Run("使用过期版本写入时返回冲突", func(t *testing.T) {
+		stale := *order
+		stale.Version--
+		_, err := stale.Refund(models.MustParseMoney("200"), "退货")
+		assert.NoError(t, err)
+		_, err = order_repo.UpdateRefund(ctx, &stale, models.OrderPaid)
+		assert.ErrorIs(t, err, repositories.ErrorVersionConflict)
+	})
+
+	t.Run("全额退完后订单变为refunded", func(t *testing.T) {
+		refund, err := order.Refund(models.MustParseMoney("200"), "退货")
+		assert.NoError(t, err)
+		_, err = order_repo.UpdateRefund(ctx, order, models.OrderPaid)
+		assert.NoError(t, err)
+		_, err = repo.Save(ctx, refund)
+		assert.NoError(t, err)
+
+		found, err := order_repo.FindByID(ctx, order.OrderID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.OrderRefunded, found.Status)
+	})
+
+	t.Run("按退款顺序查询退款记录", func(t *testing.T) {
+		refunds, err := repo.FindByOrderID(ctx, order.OrderID)
+		assert.NoError(t, err)
+		if assert.Len(t, refunds, 2) {
+			assert.Equal(t, models.MustParseMoney("100"), refunds[0].Amount)
+			assert.Equal(t, "商品破损", refunds[0].Reason)
+			assert.Equal(t, models.MustParseMoney("200"), refunds[1].Amount)
+		}
+
+		none, err := repo.FindByOrderID(ctx, order.OrderID+1)
+		assert.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM refunds").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/i18n"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL 中可以通过重跑整个事务解决的错误码
+const (
+	ErCodeLockWaitTimeout uint16 = 1205 // Lock wait timeout exceeded
+	ErCodeDeadlock        uint16 = 1213 // Deadlock found when trying to get lock
+)
+
+// txRetries 事务重试次数指标，按错误码分组（通过 /debug/vars 暴露）
+var txRetries = expvar.NewMap("db_tx_retries")
+
+// RetryPolicy 事务重试策略
+type RetryPolicy struct {
+	MaxAttempts    int           // 最多执行次数（含第一次），<=1 表示不重试
+	BaseBackoff    time.Duration // 第一次重试前的退避上限，之后按 2 的指数增长
+	MaxBackoff     time.Duration // 单次退避的最大值
+	RetryableCodes []uint16      // 可重试的 MySQL 错误码
+	// OnRetry 每次重试前回调，为空时通过 Logger 记录日志
+	OnRetry func(attempt int, err error, wait time.Duration)
+	// Logger 记录重试日志，为空时按默认语言输出（i18n.Default）
+	Logger repositories.Logger
+}
+
+// DefaultRetryPolicy 默认策略：死锁与锁等待超时最多执行 3 次
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		BaseBackoff:    50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		RetryableCodes: []uint16{ErCodeDeadlock, ErCodeLockWaitTimeout},
+	}
+}
+
+// IsRetryable 判断 err（可被包装）是否为可重试的 MySQL 错误
+func (p RetryPolicy) IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return slices.Contains(p.RetryableCodes, mysqlErr.Number)
+}
+
+// Backoff 返回第 attempt 次重试前的等待时间（full jitter：[0, min(MaxBackoff, BaseBackoff*2^(attempt-1))]）
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseBackoff <= 0 || attempt <= 0 {
+		return 0
+	}
+	ceiling := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || ceiling < p.MaxBackoff); i++ {
+		ceiling *= 2
+	}
+	if p.MaxBackoff > 0 && ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// Run 执行 fn，遇到可重试错误时退避后重新执行整个 fn
+func (p RetryPolicy) Run(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.IsRetryable(err) {
+			return err
+		}
+
+		wait := p.Backoff(attempt)
+		p.reportRetry(ctx, attempt, err, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Join(err, ctxErr)
+		}
+	}
+}
+
+func (p RetryPolicy) reportRetry(ctx context.Context, attempt int, err error, wait time.Duration) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		txRetries.Add(strconv.Itoa(int(mysqlErr.Number)), 1)
+	}
+	if p.OnRetry != nil {
+		p.OnRetry(attempt, err, wait)
+		return
+	}
+	logger := p.Logger
+	if logger == nil {
+		logger = i18n.Default()
+	}
+	logger.Log(ctx, "log.tx_retry", map[string]any{"attempt": attempt, "wait": wait, "error": err})
+}
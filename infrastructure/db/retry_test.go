@@ -0,0 +1,138 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_IsRetryable(t *testing.T) {
+	policy := db.DefaultRetryPolicy()
+
+	t.Run("死锁可重试", func(t *testing.T) {
+		assert.True(t, policy.IsRetryable(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	})
+
+	t.Run("锁等待超时可重试", func(t *testing.T) {
+		assert.True(t, policy.IsRetryable(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}))
+	})
+
+	t.Run("被包装的错误同样识别", func(t *testing.T) {
+		err := fmt.Errorf("users表更新失败: %w", &mysql.MySQLError{Number: 1213})
+		assert.True(t, policy.IsRetryable(err))
+	})
+
+	t.Run("其他错误不可重试", func(t *testing.T) {
+		assert.False(t, policy.IsRetryable(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+		assert.False(t, policy.IsRetryable(errors.New("Deadlock")))
+		assert.False(t, policy.IsRetryable(nil))
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := db.RetryPolicy{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		wait := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, wait, time.Duration(0))
+		assert.LessOrEqual(t, wait, 40*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_Run(t *testing.T) {
+	ctx := context.Background()
+	var retried []int
+	policy := db.RetryPolicy{
+		MaxAttempts:    3,
+		RetryableCodes: []uint16{db.ErCodeDeadlock, db.ErCodeLockWaitTimeout},
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			retried = append(retried, attempt)
+		},
+	}
+
+	t.Run("重试后成功", func(t *testing.T) {
+		retried = nil
+		calls := 0
+		err := policy.Run(ctx, func() error {
+			calls++
+			if calls < 3 {
+				return &mysql.MySQLError{Number: 1213}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int{1, 2}, retried)
+	})
+
+	t.Run("超过最大次数返回最后一次错误", func(t *testing.T) {
+		retried = nil
+		calls := 0
+		err := policy.Run(ctx, func() error {
+			calls++
+			return &mysql.MySQLError{Number: 1205}
+		})
+		var mysqlErr *mysql.MySQLError
+		assert.ErrorAs(t, err, &mysqlErr)
+		assert.Equal(t, 3, calls)
+		assert.Len(t, retried, 2)
+	})
+
+	t.Run("未设置 OnRetry 时通过 Logger 记录", func(t *testing.T) {
+		logger := &keyRecorder{}
+		logged := policy
+		logged.OnRetry = nil
+		logged.Logger = logger
+		calls := 0
+		err := logged.Run(ctx, func() error {
+			calls++
+			if calls < 2 {
+				return &mysql.MySQLError{Number: 1213}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"log.tx_retry"}, logger.keys)
+	})
+
+	t.Run("不可重试错误立即返回", func(t *testing.T) {
+		retried = nil
+		calls := 0
+		err := policy.Run(ctx, func() error {
+			calls++
+			return errors.New("db error")
+		})
+		assert.ErrorContains(t, err, "db error")
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, retried)
+	})
+
+	t.Run("ctx取消时停止重试", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		slow := policy
+		slow.BaseBackoff = time.Second
+		calls := 0
+		err := slow.Run(canceled, func() error {
+			calls++
+			return &mysql.MySQLError{Number: 1213}
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+// keyRecorder 记录日志的文案键
+type keyRecorder struct {
+	keys []string
+}
+
+func (r *keyRecorder) Log(_ context.Context, key string, _ map[string]any) {
+	r.keys = append(r.keys, key)
+}
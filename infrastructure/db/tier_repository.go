@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type GormTierRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTierRepository(db *gorm.DB) repositories.TierRepository {
+	return &GormTierRepository{db: db}
+}
+
+func (r *GormTierRepository) FindThresholds(ctx context.Context) (models.TierSchedule, error) {
+	thresholds := models.TierSchedule{}
+	if err := conn(ctx, r.db).Order("min_consumption").Find(&thresholds).Error; err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
+
+// SaveThreshold 先按等级名称查询再新增或修改；不使用 ON DUPLICATE KEY UPDATE，
+// 因为 MySQL 在任一唯一索引（含 idx_tier_min）冲突时都会改为更新，会覆盖已有等级的门槛
+func (r *GormTierRepository) SaveThreshold(ctx context.Context, threshold *models.TierThreshold) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing models.TierThreshold
+		err := tx.Where("tier = ?", threshold.Tier).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(threshold).Error
+		} else if err != nil {
+			return err
+		}
+		threshold.ID = existing.ID
+		threshold.CreatedAt = existing.CreatedAt
+		return tx.Model(&models.TierThreshold{}).Where("tier = ?", threshold.Tier).
+			Updates(map[string]any{"min_consumption": threshold.MinConsumption}).Error
+	})
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+		return repositories.ErrorDuplicate
+	}
+	return err
+}
+
+func (r *GormTierRepository) SaveChange(ctx context.Context, change *models.TierChange) (uint64, error) {
+	if err := conn(ctx, r.db).Create(change).Error; err != nil {
+		return uint64(0), err
+	}
+	return change.ID, nil
+}
+
+func (r *GormTierRepository) FindChangesByUserID(ctx context.Context, userID uint64) ([]*models.TierChange, error) {
+	changes := []*models.TierChange{}
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,100 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTierRepository(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	if err := dbConn.AutoMigrate(&models.TierThreshold{}, &models.TierChange{}); err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		for _, table := range []string{"tier_thresholds", "tier_changes", "users"} {
+			if err := dbConn.Exec("DELETE FROM " + table).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cleanup()
+	ctx := context.Background()
+	repo := db.NewGormTierRepository(dbConn)
+
+	t.Run("按等级名称新增或修改门槛", func(t *testing.T) {
+		for _, c := range []struct{ tier, min string }{{"gold", "10000"}, {"bronze", "0"}, {"silver", "500"}, {"silver", "1000"}} {
+			threshold, err := models.NewTierThreshold(c.tier, models.MustParseMoney(c.min))
+			assert.NoError(t, err)
+			assert.NoError(t, repo.SaveThreshold(ctx, threshold))
+		}
+
+		schedule, err := repo.FindThresholds(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, schedule, 3) {
+			assert.Equal(t, "bronze", schedule[0].Tier)
+			assert.Equal(t, "silver", schedule[1].Tier)
+			assert.Equal(t, models.MustParseMoney("1000"), schedule[1].MinConsumption)
+			assert.Equal(t, "gold", schedule[2].Tier)
+		}
+	})
+
+	t.Run("门槛重复", func(t *testing.T) {
+		threshold, _ := models.NewTierThreshold("platinum", models.MustParseMoney("10000"))
+		assert.ErrorIs(t, repo.SaveThreshold(ctx, threshold), repositories.ErrorDuplicate)
+
+		// 修改已有等级的门槛与其他等级相同时也不覆盖
+		threshold, _ = models.NewTierThreshold("silver", models.MustParseMoney("10000"))
+		assert.ErrorIs(t, repo.SaveThreshold(ctx, threshold), repositories.ErrorDuplicate)
+
+		schedule, err := repo.FindThresholds(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, schedule, 3) {
+			assert.Equal(t, models.MustParseMoney("1000"), schedule[1].MinConsumption)
+			assert.Equal(t, models.MustParseMoney("10000"), schedule[2].MinConsumption)
+			assert.Equal(t, "gold", schedule[2].Tier)
+		}
+	})
+
+	t.Run("等级变化记录", func(t *testing.T) {
+		for _, to := range []string{"bronze", "silver"} {
+			_, err := repo.SaveChange(ctx, &models.TierChange{UserID: 1001, ToTier: to, TotalConsumption: models.MustParseMoney("1000")})
+			assert.NoError(t, err)
+		}
+		changes, err := repo.FindChangesByUserID(ctx, 1001)
+		assert.NoError(t, err)
+		if assert.Len(t, changes, 2) {
+			assert.Equal(t, "bronze", changes[0].ToTier)
+			assert.Equal(t, "silver", changes[1].ToTier)
+			assert.False(t, changes[1].ChangedAt.IsZero())
+		}
+	})
+
+	t.Run("按等级查询用户", func(t *testing.T) {
+		userRepo := db.NewGormUserRepository(dbConn)
+		for i, tier := range []string{"gold", "silver", "gold", ""} {
+			id := uint64(2001 + i)
+			user := newTestUser(t, id, "tier", "tier"+string(rune('a'+i))+"@example.com")
+			user.Tier = tier
+			_, err := userRepo.Save(ctx, user)
+			assert.NoError(t, err)
+		}
+		users, err := userRepo.FindByTier(ctx, "gold", 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, users, 2) {
+			assert.Equal(t, uint64(2001), users[0].ID)
+			assert.Equal(t, uint64(2003), users[1].ID)
+		}
+		users, err = userRepo.FindByTier(ctx, "gold", 1, 10)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+
+	// 清空环境
+	cleanup()
+}
@@ -2,21 +2,132 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 
 	"gorm.io/gorm"
 )
 
+// txKey 事务状态在 context 中的键
+type txKey struct{}
+
+// txState 保存在 context 中的事务句柄及嵌套深度
+type txState struct {
+	tx          *gorm.DB
+	depth       int
+	afterCommit []func(ctx context.Context) // 本层登记的提交后回调，内层成功时并入外层
+}
+
 type GormTransactionManager struct {
-	db *gorm.DB
+	db    *gorm.DB
+	retry RetryPolicy
 }
 
+// NewTransactionManager 使用默认重试策略（见 DefaultRetryPolicy）
 func NewTransactionManager(db *gorm.DB) repositories.TransactionManager {
-	return &GormTransactionManager{db: db}
+	return NewTransactionManagerWithRetry(db, DefaultRetryPolicy())
+}
+
+// NewTransactionManagerWithRetry 使用指定的重试策略
+func NewTransactionManagerWithRetry(db *gorm.DB, retry RetryPolicy) repositories.TransactionManager {
+	return &GormTransactionManager{db: db, retry: retry}
+}
+
+// Transaction 开启事务，并把事务句柄放入 ctx 传给 fn
+// fn 返回错误时回滚，否则提交；ctx 已在事务中时改为 SAVEPOINT（见 repositories.TransactionManager）
+// 最外层事务遇到死锁、锁等待超时等可重试错误时，按重试策略回滚并重新执行整个 fn，
+// 因此 fn 不应在事务外留下副作用；嵌套事务不单独重试，错误交给最外层处理
+func (m *GormTransactionManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.TransactionWithOptions(ctx, repositories.TxOptions{}, fn)
 }
 
-func (m *GormTransactionManager) Transaction(fn func() error) error {
-	return m.db.Transaction(func(tx *gorm.DB) error {
-		return fn()
+// TransactionWithOptions 按 opts 开启事务，隔离级别与只读模式通过 sql.TxOptions 交给驱动处理
+func (m *GormTransactionManager) TransactionWithOptions(ctx context.Context, opts repositories.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// 嵌套事务：gorm 在已有事务的句柄上调用 Transaction 时会自动使用 SAVEPOINT / ROLLBACK TO
+	if state, ok := currentTx(ctx); ok {
+		inner := &txState{depth: state.depth + 1}
+		err := state.tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			inner.tx = tx
+			return fn(context.WithValue(ctx, txKey{}, inner))
+		})
+		if err == nil {
+			// 内层的回调要等最外层提交；回滚到 SAVEPOINT 时随之丢弃
+			state.afterCommit = append(state.afterCommit, inner.afterCommit...)
+		}
+		return err
+	}
+
+	var committed *txState
+	err := m.retry.Run(ctx, func() error {
+		// 每次尝试使用新的状态，被重试的尝试登记的回调不会执行
+		state := &txState{depth: 1}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			state.tx = tx
+			return fn(context.WithValue(ctx, txKey{}, state))
+		}, sqlTxOptions(opts))
+		if err == nil {
+			committed = state
+		}
+		return err
 	})
+	if err != nil {
+		return err
+	}
+	for _, callback := range committed.afterCommit {
+		callback(ctx)
+	}
+	return nil
+}
+
+// AfterCommit 见 repositories.TransactionManager
+func (m *GormTransactionManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if state, ok := currentTx(ctx); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// TransactionDepth 返回 ctx 的事务嵌套深度，不在事务中时为 0
+func (m *GormTransactionManager) TransactionDepth(ctx context.Context) int {
+	if state, ok := currentTx(ctx); ok {
+		return state.depth
+	}
+	return 0
+}
+
+// sqlTxOptions 把领域层的事务选项映射为 database/sql 的选项
+func sqlTxOptions(opts repositories.TxOptions) *sql.TxOptions {
+	isolation := sql.LevelDefault
+	switch opts.Isolation {
+	case repositories.IsolationReadCommitted:
+		isolation = sql.LevelReadCommitted
+	case repositories.IsolationRepeatableRead:
+		isolation = sql.LevelRepeatableRead
+	case repositories.IsolationSerializable:
+		isolation = sql.LevelSerializable
+	}
+	return &sql.TxOptions{Isolation: isolation, ReadOnly: opts.ReadOnly}
+}
+
+func currentTx(ctx context.Context) (*txState, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	return state, ok && state != nil
+}
+
+// conn 返回 ctx 中的事务句柄；不在事务中时返回绑定了 ctx 的根连接
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := currentTx(ctx); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
 }
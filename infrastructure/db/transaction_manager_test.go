@@ -0,0 +1,376 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTestTxDB(t *testing.T) *gorm.DB {
+	// 使用测试的数据库
+	cfg := &config.DatabaseConfig{
+		Host:      "localhost",
+		Port:      3306,
+		User:      "gouser",
+		Password:  "StrongPass123!",
+		DBName:    "go_dev_test",
+		Charset:   "utf8mb4",
+		ParseTime: true,
+	}
+
+	dbConn, err := db.NewDB(cfg)
+	assert.NoError(t, err, "数据库连接失败")
+
+	// 同步表结构
+	for _, model := range []interface{}{&models.User{}, &models.Order{}} {
+		if !dbConn.Migrator().HasTable(model) {
+			if err := dbConn.Migrator().CreateTable(model); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := dbConn.AutoMigrate(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return dbConn
+}
+
+func TestTransactionManager_Transaction(t *testing.T) {
+	// 连接db
+	dbConn := setupTestTxDB(t)
+	ctx := context.Background()
+	tm := db.NewTransactionManager(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+	order_repo := db.NewGormOrderRepository(dbConn)
+
+	user := newTestUser(t, 10001, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	_, err := user_repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	t.Run("users更新后失败时整体回滚", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			rows, err := user_repo.AddTotalConsumption(txCtx, user.ID, models.MustParseMoney("2000"))
+			assert.NoError(t, err)
+			assert.Equal(t, int8(1), rows)
+
+			// 事务内可以读到未提交的修改
+			inTx, err := user_repo.FindByID(txCtx, user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, models.MustParseMoney("3000"), inTx.TotalConsumption)
+
+			return errors.New("模拟订单写入失败")
+		})
+		assert.ErrorContains(t, err, "模拟订单写入失败")
+
+		// 验证users的修改已回滚
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+	})
+
+	t.Run("全部成功时提交", func(t *testing.T) {
+		var orderID uint64
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			if _, err := user_repo.AddTotalConsumption(txCtx, user.ID, models.MustParseMoney("500")); err != nil {
+				return err
+			}
+			id, err := order_repo.Save(txCtx, &models.Order{UserID: user.ID, Amount: models.MustParseMoney("500"), Status: models.OrderPending})
+			orderID = id
+			return err
+		})
+		assert.NoError(t, err)
+
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1500"), found.TotalConsumption)
+
+		order, err := order_repo.FindByID(ctx, orderID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("500"), order.Amount)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionManager_Nested(t *testing.T) {
+	// 连接db
+	dbConn := setupTestTxDB(t)
+	ctx := context.Background()
+	tm := db.NewTransactionManager(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+	order_repo := db.NewGormOrderRepository(dbConn)
+
+	user := newTestUser(t, 10001, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	_, err := user_repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	// 每个用例结束时消费总额都回到初始值，按与初始值的差额增量修改
+	setConsumption := func(txCtx context.Context, amount models.Money) error {
+		delta, err := amount.Sub(user.TotalConsumption)
+		if err != nil {
+			return err
+		}
+		_, err = user_repo.AddTotalConsumption(txCtx, user.ID, delta)
+		return err
+	}
+
+	t.Run("嵌套深度", func(t *testing.T) {
+		assert.Equal(t, 0, tm.TransactionDepth(ctx))
+		err := tm.Transaction(ctx, func(outerCtx context.Context) error {
+			assert.Equal(t, 1, tm.TransactionDepth(outerCtx))
+			return tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+				assert.Equal(t, 2, tm.TransactionDepth(innerCtx))
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("内层失败外层成功", func(t *testing.T) {
+		var orderID uint64
+		err := tm.Transaction(ctx, func(outerCtx context.Context) error {
+			id, err := order_repo.Save(outerCtx, &models.Order{UserID: user.ID, Amount: models.MustParseMoney("100"), Status: models.OrderPending})
+			if err != nil {
+				return err
+			}
+			orderID = id
+
+			innerErr := tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+				if err := setConsumption(innerCtx, models.MustParseMoney("5000")); err != nil {
+					return err
+				}
+				return errors.New("内层失败")
+			})
+			assert.ErrorContains(t, innerErr, "内层失败")
+			// 外层吞掉内层错误，继续提交
+			return nil
+		})
+		assert.NoError(t, err)
+
+		// 内层修改回滚到 SAVEPOINT
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+
+		// 外层修改已提交
+		_, err = order_repo.FindByID(ctx, orderID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("内层成功外层失败", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(outerCtx context.Context) error {
+			if err := tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+				return setConsumption(innerCtx, models.MustParseMoney("6000"))
+			}); err != nil {
+				return err
+			}
+			return errors.New("外层失败")
+		})
+		assert.ErrorContains(t, err, "外层失败")
+
+		// 内层虽然成功，但随外层一起回滚
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+	})
+
+	t.Run("内层panic导致整体回滚并继续传播", func(t *testing.T) {
+		assert.PanicsWithValue(t, "内层panic", func() {
+			_ = tm.Transaction(ctx, func(outerCtx context.Context) error {
+				if err := setConsumption(outerCtx, models.MustParseMoney("7000")); err != nil {
+					return err
+				}
+				return tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+					panic("内层panic")
+				})
+			})
+		})
+
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionManager_AfterCommit(t *testing.T) {
+	// 连接db
+	dbConn := setupTestTxDB(t)
+	ctx := context.Background()
+	tm := db.NewTransactionManager(dbConn)
+
+	t.Run("不在事务中时立即执行", func(t *testing.T) {
+		called := false
+		tm.AfterCommit(ctx, func(context.Context) { called = true })
+		assert.True(t, called)
+	})
+
+	t.Run("最外层提交后按登记顺序执行", func(t *testing.T) {
+		var got []string
+		err := tm.Transaction(ctx, func(outerCtx context.Context) error {
+			tm.AfterCommit(outerCtx, func(cbCtx context.Context) {
+				assert.Equal(t, 0, tm.TransactionDepth(cbCtx), "回调的 ctx 不携带事务")
+				got = append(got, "outer")
+			})
+			err := tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+				tm.AfterCommit(innerCtx, func(context.Context) { got = append(got, "inner") })
+				return nil
+			})
+			assert.Empty(t, got, "内层提交时不执行")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, got)
+	})
+
+	t.Run("回滚时丢弃", func(t *testing.T) {
+		var got []string
+		err := tm.Transaction(ctx, func(outerCtx context.Context) error {
+			tm.AfterCommit(outerCtx, func(context.Context) { got = append(got, "outer") })
+			innerErr := tm.Transaction(outerCtx, func(innerCtx context.Context) error {
+				tm.AfterCommit(innerCtx, func(context.Context) { got = append(got, "inner") })
+				return errors.New("内层失败")
+			})
+			assert.Error(t, innerErr)
+			return nil // 外层吞掉内层错误，继续提交
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"outer"}, got, "回滚到 SAVEPOINT 的内层回调被丢弃")
+
+		got = nil
+		err = tm.Transaction(ctx, func(txCtx context.Context) error {
+			tm.AfterCommit(txCtx, func(context.Context) { got = append(got, "outer") })
+			return errors.New("外层失败")
+		})
+		assert.Error(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestTransactionManager_TransactionWithOptions(t *testing.T) {
+	// 连接db
+	dbConn := setupTestTxDB(t)
+	ctx := context.Background()
+	tm := db.NewTransactionManager(dbConn)
+	user_repo := db.NewGormUserRepository(dbConn)
+
+	user := newTestUser(t, 10001, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	_, err := user_repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	// 事务内两次读取之间由另一个事务提交修改，返回两次读到的消费总额
+	readTwice := func(t *testing.T, isolation repositories.IsolationLevel, delta models.Money) (models.Money, models.Money) {
+		var first, second models.Money
+		opts := repositories.TxOptions{Isolation: isolation}
+		err := tm.TransactionWithOptions(ctx, opts, func(txCtx context.Context) error {
+			found, err := user_repo.FindByID(txCtx, user.ID)
+			if err != nil {
+				return err
+			}
+			first = found.TotalConsumption
+
+			// 使用不带事务的ctx在另一条连接上提交修改
+			if _, err := user_repo.AddTotalConsumption(ctx, user.ID, delta); err != nil {
+				return err
+			}
+
+			found, err = user_repo.FindByID(txCtx, user.ID)
+			if err != nil {
+				return err
+			}
+			second = found.TotalConsumption
+			return nil
+		})
+		assert.NoError(t, err)
+		return first, second
+	}
+
+	t.Run("READ COMMITTED读到其他事务已提交的修改", func(t *testing.T) {
+		first, second := readTwice(t, repositories.IsolationReadCommitted, models.MustParseMoney("100"))
+		assert.Equal(t, models.MustParseMoney("1000"), first)
+		assert.Equal(t, models.MustParseMoney("1100"), second)
+	})
+
+	t.Run("REPEATABLE READ保持一致性快照", func(t *testing.T) {
+		first, second := readTwice(t, repositories.IsolationRepeatableRead, models.MustParseMoney("-100"))
+		assert.Equal(t, models.MustParseMoney("1100"), first)
+		assert.Equal(t, models.MustParseMoney("1100"), second)
+	})
+
+	t.Run("只读事务拒绝写入", func(t *testing.T) {
+		opts := repositories.TxOptions{ReadOnly: true}
+		err := tm.TransactionWithOptions(ctx, opts, func(txCtx context.Context) error {
+			found, err := user_repo.FindByID(txCtx, user.ID)
+			if err != nil {
+				return err
+			}
+			_, err = user_repo.AddTotalConsumption(txCtx, found.ID, models.MustParseMoney("100"))
+			return err
+		})
+		assert.ErrorContains(t, err, "READ ONLY")
+
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+	})
+
+	t.Run("事务超时", func(t *testing.T) {
+		opts := repositories.TxOptions{Timeout: 100 * time.Millisecond}
+		start := time.Now()
+		err := tm.TransactionWithOptions(ctx, opts, func(txCtx context.Context) error {
+			if _, err := user_repo.AddTotalConsumption(txCtx, user.ID, models.MustParseMoney("100")); err != nil {
+				return err
+			}
+			<-txCtx.Done()
+			_, err := user_repo.FindByID(txCtx, user.ID)
+			return err
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		// 超时的事务被回滚
+		found, err := user_repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.MustParseMoney("1000"), found.TotalConsumption)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM orders").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
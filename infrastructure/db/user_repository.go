@@ -1,10 +1,12 @@
 package db
 
 import (
+	"context"
 	"errors"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -16,9 +18,14 @@ func NewGormUserRepository(db *gorm.DB) repositories.UserRepository {
 	return &GormUserRepository{db: db}
 }
 
-func (r *GormUserRepository) FindByID(id uint64) (*models.User, error) {
+// scoped 当前 ctx 下的查询句柄，默认过滤已注销（软删除）的用户
+func (r *GormUserRepository) scoped(ctx context.Context) *gorm.DB {
+	return scoped(ctx, conn(ctx, r.db))
+}
+
+func (r *GormUserRepository) FindByID(ctx context.Context, id uint64) (*models.User, error) {
 	var user models.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.scoped(ctx).First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repositories.ErrorNotFound
 		}
@@ -27,9 +34,21 @@ func (r *GormUserRepository) FindByID(id uint64) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
+func (r *GormUserRepository) FindByIDForUpdate(ctx context.Context, id uint64, mode repositories.LockMode) (*models.User, error) {
+	tx, err := lockForUpdate(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := scoped(ctx, tx).First(&user, id).Error; err != nil {
+		return nil, lockError(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) FindByEmail(ctx context.Context, canonicalEmail string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.scoped(ctx).Where("canonical_email = ?", canonicalEmail).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repositories.ErrorNotFound
 		}
@@ -38,23 +57,85 @@ func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *GormUserRepository) Save(user *models.User) (uint64, error) {
-	if err := r.db.Save(user).Error; err != nil {
+func (r *GormUserRepository) FindByTier(ctx context.Context, tier string, offset int, limit int) ([]*models.User, error) {
+	users := []*models.User{}
+	err := r.scoped(ctx).Where("tier = ?", tier).Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *GormUserRepository) Save(ctx context.Context, user *models.User) (uint64, error) {
+	// 规范化邮箱由 models.CreateUser 按配置的邮箱策略计算，仓储不自行选择策略
+	if user.CanonicalEmail == "" {
+		return uint64(0), models.ErrInvalidArgument.With("field", "canonical_email").Wrap(repositories.ErrorInvalid)
+	}
+	if err := r.scoped(ctx).Save(user).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+			return uint64(0), repositories.ErrorDuplicate
+		}
 		return uint64(0), err
 	}
 	return user.ID, nil
 }
 
-func (r *GormUserRepository) UpdateTotalConsumption(user *models.User) (int8, error) {
-	result := r.db.Model(user).
-		Where("id = ?", user.ID).
-		Update("total_consumption", user.TotalConsumption)
-	affected_num, err := result.RowsAffected, result.Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return int8(0), repositories.ErrorNotFound
+func (r *GormUserRepository) UpdateFields(ctx context.Context, user *models.User, fields ...string) (int8, error) {
+	if len(fields) == 0 {
+		return int8(0), nil
+	}
+	// Select 指定的字段即使为零值也会写入；版本号随同更新
+	next := *user
+	next.Version++
+	result := r.scoped(ctx).Model(&models.User{}).
+		Where("id = ? AND version = ?", user.ID, user.Version).
+		Select(append(fields, "Version")).
+		Updates(&next)
+	if result.Error != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(result.Error, &mysqlErr) && mysqlErr.Number == ErCodeDupEntry {
+			return int8(0), repositories.ErrorDuplicate
 		}
+		return int8(0), result.Error
+	}
+	if result.RowsAffected == 0 {
+		found, err := exists(r.scoped(ctx), &models.User{}, "id = ?", user.ID)
+		if err != nil {
+			return int8(0), err
+		}
+		if found {
+			return int8(0), repositories.ErrorVersionConflict
+		}
+		return int8(0), repositories.ErrorNotFound
+	}
+	user.Version++
+	return int8(result.RowsAffected), nil
+}
+
+func (r *GormUserRepository) AddTotalConsumption(ctx context.Context, userID uint64, delta models.Money) (int8, error) {
+	// 增量更新同样推进版本号，使持有旧版本的乐观写入能够发现冲突
+	// 金额以字符串传入，CAST 为 decimal 后参与运算，避免 MySQL 隐式转换为浮点数
+	result := r.scoped(ctx).Model(&models.User{}).
+		Where("id = ? AND total_consumption + CAST(? AS DECIMAL(12,2)) >= 0", userID, delta).
+		Updates(map[string]interface{}{
+			"total_consumption": gorm.Expr("total_consumption + CAST(? AS DECIMAL(12,2))", delta),
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return int8(0), result.Error
+	}
+	if result.RowsAffected == 1 {
+		return int8(1), nil
+	}
+
+	// 未更新时区分用户不存在与余额不足
+	found, err := exists(r.scoped(ctx), &models.User{}, "id = ?", userID)
+	if err != nil {
 		return int8(0), err
 	}
-	return int8(affected_num), nil
+	if !found {
+		return int8(0), repositories.ErrorNotFound
+	}
+	return int8(0), models.ErrInsufficientConsumption
 }
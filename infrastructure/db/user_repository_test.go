@@ -1,7 +1,9 @@
 package db_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
@@ -47,37 +49,45 @@ func setupTestUserDB(t *testing.T) *gorm.DB {
 	return dbConn
 }
 
+// newTestUser 经 models.CreateUser 创建用户（规范化邮箱由邮箱策略计算），id 为0时由数据库分配
+func newTestUser(t *testing.T, id uint64, name string, email string) *models.User {
+	t.Helper()
+	user, err := models.CreateUser(name, email, models.DefaultEmailPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.ID = id
+	return user
+}
+
 func TestUserRepository_FindByID(t *testing.T) {
 	// 连接db
 	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
 	repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("成功查找到用户ID", func(t *testing.T) {
-		user := &models.User{
-			ID:               uint64(1001),
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 2000,
-		}
+		user := newTestUser(t, 1001, "test", "test@example.com")
+		user.TotalConsumption = models.MustParseMoney("2000")
 
 		// 执行保存
-		if _, err := repo.Save(user); err != nil {
+		if _, err := repo.Save(ctx, user); err != nil {
 			t.Fatal(err)
 		}
 
 		// 查找
-		foundUser, err := repo.FindByID(user.ID)
+		foundUser, err := repo.FindByID(ctx, user.ID)
 		// 验证数据
 		assert.NoError(t, err)
 		assert.Equal(t, user.ID, foundUser.ID)
 		assert.Equal(t, "test", foundUser.Name)
 		assert.Equal(t, "test@example.com", foundUser.Email)
-		assert.Equal(t, float64(2000), foundUser.TotalConsumption)
+		assert.Equal(t, models.MustParseMoney("2000"), foundUser.TotalConsumption)
 	})
 
 	t.Run("无法找到用户ID", func(t *testing.T) {
 		// 查找
-		_, err := repo.FindByID(uint64(1002))
+		_, err := repo.FindByID(ctx, uint64(1002))
 		assert.ErrorIs(t, err, repositories.ErrorNotFound)
 	})
 
@@ -90,37 +100,45 @@ func TestUserRepository_FindByID(t *testing.T) {
 func TestUserRepository_FindByEmail(t *testing.T) {
 	// 连接db
 	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
 	repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("成功查找到用户邮箱", func(t *testing.T) {
-		user := &models.User{
-			ID:               uint64(1001),
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 2000,
-		}
+		user := newTestUser(t, 1001, "test", "test@example.com")
+		user.TotalConsumption = models.MustParseMoney("2000")
 
 		// 执行保存
-		if _, err := repo.Save(user); err != nil {
+		if _, err := repo.Save(ctx, user); err != nil {
 			t.Fatal(err)
 		}
 
 		// 查找
-		foundUser, err := repo.FindByEmail(user.Email)
+		foundUser, err := repo.FindByEmail(ctx, user.Email)
 		// 验证数据
 		assert.NoError(t, err)
 		assert.Equal(t, user.ID, foundUser.ID)
 		assert.Equal(t, "test", foundUser.Name)
 		assert.Equal(t, "test@example.com", foundUser.Email)
-		assert.Equal(t, float64(2000), foundUser.TotalConsumption)
+		assert.Equal(t, models.MustParseMoney("2000"), foundUser.TotalConsumption)
 	})
 
 	t.Run("无法找到用户邮箱", func(t *testing.T) {
 		// 查找
-		_, err := repo.FindByEmail("cannot@find.com")
+		_, err := repo.FindByEmail(ctx, "cannot@find.com")
 		assert.ErrorIs(t, err, repositories.ErrorNotFound)
 	})
 
+	t.Run("规范化后相同的邮箱不能重复保存", func(t *testing.T) {
+		user, err := models.CreateUser("Test", "Test@Example.com", nil)
+		assert.NoError(t, err)
+		_, err = repo.Save(ctx, user)
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+
+		foundUser, err := repo.FindByEmail(ctx, "test@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1001), foundUser.ID)
+	})
+
 	// 清空环境
 	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
 		t.Fatal(err)
@@ -130,26 +148,30 @@ func TestUserRepository_FindByEmail(t *testing.T) {
 func TestUserRepository_Save(t *testing.T) {
 	// 连接db
 	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
 	repo := db.NewGormUserRepository(dbConn)
 
 	t.Run("成功保存用户", func(t *testing.T) {
-		user := &models.User{
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 0,
-		}
+		user := newTestUser(t, 0, "test", "test@example.com")
 
 		// 执行保存
-		userID, err := repo.Save(user)
+		userID, err := repo.Save(ctx, user)
 		assert.NoError(t, err)
 		assert.NotZero(t, userID)
 
 		// 验证数据
-		foundUser, err := repo.FindByID(userID)
+		foundUser, err := repo.FindByID(ctx, userID)
 		assert.NoError(t, err)
 		assert.Equal(t, "test", foundUser.Name)
 		assert.Equal(t, "test@example.com", foundUser.Email)
-		assert.Equal(t, float64(0), foundUser.TotalConsumption)
+		assert.Equal(t, models.MustParseMoney("0"), foundUser.TotalConsumption)
+	})
+
+	t.Run("缺少规范化邮箱时拒绝保存", func(t *testing.T) {
+		// 仓储不选择邮箱策略，用户必须经 models.CreateUser 创建
+		_, err := repo.Save(ctx, &models.User{Name: "raw", Email: "raw@example.com"})
+		assert.ErrorIs(t, err, repositories.ErrorInvalid)
+		assert.Equal(t, models.CategoryValidation, models.CategoryOf(err))
 	})
 
 	// 清空环境
@@ -158,50 +180,193 @@ func TestUserRepository_Save(t *testing.T) {
 	}
 }
 
-func TestUserRepository_UpdateTotalConsumption(t *testing.T) {
+func TestUserRepository_AddTotalConsumption(t *testing.T) {
 	// 连接db
 	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
 	repo := db.NewGormUserRepository(dbConn)
 
-	t.Run("成功更新用户的消费总额", func(t *testing.T) {
-		user := &models.User{
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 0,
-		}
-		userID, err := repo.Save(user)
+	user := newTestUser(t, 0, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	userID, err := repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	t.Run("按增量增加消费总额", func(t *testing.T) {
+		affected_num, err := repo.AddTotalConsumption(ctx, userID, models.MustParseMoney("250.5"))
 		assert.NoError(t, err)
+		assert.Equal(t, int8(1), affected_num)
 
-		// 更新
-		user.TotalConsumption += 1000
-		affected_rows, err := repo.UpdateTotalConsumption(user)
+		foundUser, err := repo.FindByID(ctx, userID)
 		assert.NoError(t, err)
-		assert.Equal(t, int8(1), int8(affected_rows))
+		assert.Equal(t, models.MustParseMoney("1250.5"), foundUser.TotalConsumption)
+	})
 
-		// 验证
-		foundUser, err := repo.FindByID(userID)
+	t.Run("扣减后为负数时拒绝", func(t *testing.T) {
+		affected_num, err := repo.AddTotalConsumption(ctx, userID, models.MustParseMoney("-2000"))
+		assert.ErrorIs(t, err, models.ErrInsufficientConsumption)
+		assert.Equal(t, int8(0), affected_num)
+
+		foundUser, err := repo.FindByID(ctx, userID)
 		assert.NoError(t, err)
-		assert.Equal(t, user.ID, foundUser.ID)
-		assert.Equal(t, user.Email, foundUser.Email)
-		assert.Equal(t, user.Name, foundUser.Name)
-		assert.Equal(t, user.TotalConsumption, foundUser.TotalConsumption)
+		assert.Equal(t, models.MustParseMoney("1250.5"), foundUser.TotalConsumption)
 	})
 
-	t.Run("更新用户消费总额时发现找不到用户", func(t *testing.T) {
-		user := &models.User{
-			ID:               888,
-			Name:             "test",
-			Email:            "test@example.com",
-			TotalConsumption: 0,
-		}
-		_, err := repo.Save(user)
+	t.Run("用户不存在", func(t *testing.T) {
+		_, err := repo.AddTotalConsumption(ctx, userID+1000, models.MustParseMoney("100"))
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepository_UpdateFields(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
+	repo := db.NewGormUserRepository(dbConn)
+
+	user := newTestUser(t, 0, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	userID, err := repo.Save(ctx, user)
+	assert.NoError(t, err)
+	_, err = repo.Save(ctx, newTestUser(t, 0, "other", "other@example.com"))
+	assert.NoError(t, err)
+
+	t.Run("只更新指定的字段", func(t *testing.T) {
+		stale, err := repo.FindByID(ctx, userID)
 		assert.NoError(t, err)
+		stale.Name = "renamed"
+		stale.TotalConsumption = models.MustParseMoney("1") // 未指定，不应写入
 
-		// 修改ID
-		user.ID = 999
-		affected_num, err := repo.UpdateTotalConsumption(user)
+		affected_num, err := repo.UpdateFields(ctx, stale, "Name")
 		assert.NoError(t, err)
-		assert.Equal(t, int8(0), affected_num)
+		assert.Equal(t, int8(1), affected_num)
+		assert.Equal(t, uint64(1), stale.Version)
+
+		foundUser, err := repo.FindByID(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, "renamed", foundUser.Name)
+		assert.Equal(t, models.MustParseMoney("1000"), foundUser.TotalConsumption)
+		assert.Equal(t, uint64(1), foundUser.Version)
+	})
+
+	t.Run("使用过期版本更新时返回冲突", func(t *testing.T) {
+		_, err := repo.UpdateFields(ctx, &models.User{ID: userID, Name: "stale"}, "Name")
+		assert.ErrorIs(t, err, repositories.ErrorVersionConflict)
+	})
+
+	t.Run("规范化邮箱已被其他用户使用", func(t *testing.T) {
+		foundUser, err := repo.FindByID(ctx, userID)
+		assert.NoError(t, err)
+		foundUser.Email, foundUser.CanonicalEmail = "Other@example.com", "other@example.com"
+		_, err = repo.UpdateFields(ctx, foundUser, "Email", "CanonicalEmail")
+		assert.ErrorIs(t, err, repositories.ErrorDuplicate)
+	})
+
+	t.Run("用户不存在", func(t *testing.T) {
+		_, err := repo.UpdateFields(ctx, &models.User{ID: userID + 1000, Name: "none"}, "Name")
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepository_FindByIDForUpdate(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
+	repo := db.NewGormUserRepository(dbConn)
+	tm := db.NewTransactionManager(dbConn)
+
+	user := newTestUser(t, 0, "test", "test@example.com")
+	user.TotalConsumption = models.MustParseMoney("1000")
+	userID, err := repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	t.Run("事务外调用返回错误", func(t *testing.T) {
+		_, err := repo.FindByIDForUpdate(ctx, userID, repositories.LockWait)
+		assert.ErrorIs(t, err, repositories.ErrorNoTransaction)
+	})
+
+	t.Run("事务内加锁查询", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			foundUser, err := repo.FindByIDForUpdate(txCtx, userID, repositories.LockWait)
+			assert.NoError(t, err)
+			assert.Equal(t, models.MustParseMoney("1000"), foundUser.TotalConsumption)
+
+			_, err = repo.FindByIDForUpdate(txCtx, userID+1000, repositories.LockWait)
+			assert.ErrorIs(t, err, repositories.ErrorNotFound)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("行已被锁定时NOWAIT与SKIP LOCKED", func(t *testing.T) {
+		err := tm.Transaction(ctx, func(txCtx context.Context) error {
+			if _, err := repo.FindByIDForUpdate(txCtx, userID, repositories.LockWait); err != nil {
+				return err
+			}
+
+			// 使用不带事务的ctx开启另一个事务（另一条连接）
+			return tm.Transaction(ctx, func(otherCtx context.Context) error {
+				_, err := repo.FindByIDForUpdate(otherCtx, userID, repositories.LockNoWait)
+				assert.ErrorIs(t, err, repositories.ErrorLocked)
+
+				_, err = repo.FindByIDForUpdate(otherCtx, userID, repositories.LockSkipLocked)
+				assert.ErrorIs(t, err, repositories.ErrorNotFound)
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+	})
+
+	// 清空环境
+	if err := dbConn.Exec("DELETE FROM users").Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	// 连接db
+	dbConn := setupTestUserDB(t)
+	ctx := context.Background()
+	repo := db.NewGormUserRepository(dbConn)
+
+	user, err := models.CreateUser("test", "test@example.com", nil)
+	assert.NoError(t, err)
+	userID, err := repo.Save(ctx, user)
+	assert.NoError(t, err)
+
+	// 注销
+	assert.NoError(t, user.Delete(time.Now()))
+	_, err = repo.UpdateFields(ctx, user, "Status", "DeletedAt")
+	assert.NoError(t, err)
+
+	t.Run("默认过滤已注销的用户", func(t *testing.T) {
+		_, err := repo.FindByID(ctx, userID)
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+		_, err = repo.FindByEmail(ctx, "test@example.com")
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+		_, err = repo.AddTotalConsumption(ctx, userID, models.MustParseMoney("1"))
+		assert.ErrorIs(t, err, repositories.ErrorNotFound)
+	})
+
+	t.Run("显式包含已注销的用户", func(t *testing.T) {
+		includeCtx := repositories.IncludeDeleted(ctx)
+		found, err := repo.FindByID(includeCtx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.UserDeleted, found.Status)
+		assert.True(t, found.DeletedAt.Valid)
+
+		affected_num, err := repo.AddTotalConsumption(includeCtx, userID, models.MustParseMoney("1"))
+		assert.NoError(t, err)
+		assert.Equal(t, int8(1), affected_num)
 	})
 
 	// 清空环境
@@ -0,0 +1,85 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+)
+
+// NewPolicy 按配置组装邮箱校验策略
+func NewPolicy(cfg config.EmailConfig) (models.EmailPolicy, error) {
+	opts := []models.EmailPolicyOption{
+		models.WithAllowedDomains(cfg.AllowDomains...),
+		models.WithDeniedDomains(cfg.DenyDomains...),
+	}
+	if cfg.DisposableDomainsFile != "" {
+		domains, err := LoadDomainList(cfg.DisposableDomainsFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, models.WithDisposableDomains(domains...))
+	}
+	if cfg.IgnorePlusTag {
+		opts = append(opts, models.WithLocalPartRules(models.IgnorePlusTag))
+	}
+	if len(cfg.IgnoreDotsDomains) > 0 {
+		opts = append(opts, models.WithLocalPartRules(models.IgnoreDotsFor(cfg.IgnoreDotsDomains...)))
+	}
+	if cfg.CheckMX {
+		opts = append(opts, models.WithMXCheck(NewDNSMXChecker(3*time.Second)))
+	}
+	return models.NewEmailPolicy(opts...), nil
+}
+
+// LoadDomainList 读取域名列表文件，每行一个域名，忽略空行与 # 开头的注释
+func LoadDomainList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
+
+// DNSMXChecker 通过DNS查询MX记录
+type DNSMXChecker struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+func NewDNSMXChecker(timeout time.Duration) *DNSMXChecker {
+	return &DNSMXChecker{resolver: net.DefaultResolver, timeout: timeout}
+}
+
+var _ models.MXChecker = (*DNSMXChecker)(nil)
+
+func (c *DNSMXChecker) HasMX(domain string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	records, err := c.resolver.LookupMX(ctx, domain)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return len(records) > 0, nil
+}
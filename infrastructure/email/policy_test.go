@@ -0,0 +1,40 @@
+package email_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/email"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDomainList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# 注释\nmailinator.com\n\n  yopmail.com  \n"), 0o644))
+
+	domains, err := email.LoadDomainList(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mailinator.com", "yopmail.com"}, domains)
+}
+
+func TestNewPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("mailinator.com\n"), 0o644))
+
+	policy, err := email.NewPolicy(config.EmailConfig{
+		DenyDomains:           []string{"163.com"},
+		DisposableDomainsFile: path,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, policy.Validate("alice@corp.com"))
+	assert.ErrorIs(t, policy.Validate("alice@163.com"), models.ErrInvalidEmail)
+	assert.ErrorIs(t, policy.Validate("alice@mailinator.com"), models.ErrInvalidEmail)
+
+	t.Run("列表文件不存在", func(t *testing.T) {
+		_, err := email.NewPolicy(config.EmailConfig{DisposableDomainsFile: filepath.Join(t.TempDir(), "missing.txt")})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,195 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// Locale 语言标签，如 zh-CN
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	EnUS Locale = "en-US"
+
+	// DefaultLocale 未配置语言时使用，也是其他语言缺少文案时的回退
+	DefaultLocale = ZhCN
+)
+
+// Params 文案中 {name} 占位符的参数
+type Params = map[string]any
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// bundles 各语言的文案，键为错误码或 log. 开头的消息键
+// 内嵌文件在编译时确定，格式错误由测试发现，因此加载失败直接 panic
+var bundles = mustLoadBundles()
+
+func mustLoadBundles() map[Locale]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[Locale]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var bundle map[string]string
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			panic(fmt.Errorf("语言文件%s格式错误: %w", entry.Name(), err))
+		}
+		loaded[Locale(strings.TrimSuffix(entry.Name(), ".json"))] = bundle
+	}
+	return loaded
+}
+
+// ParseLocale 解析语言标签，不区分大小写，- 与 _ 等价；只有语言部分时（如 en）匹配该语言的已支持标签
+// 为空时返回 DefaultLocale
+func ParseLocale(tag string) (Locale, error) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return DefaultLocale, nil
+	}
+	for locale := range bundles {
+		if strings.EqualFold(string(locale), tag) {
+			return locale, nil
+		}
+	}
+	for _, locale := range []Locale{ZhCN, EnUS} {
+		if lang, _, _ := strings.Cut(string(locale), "-"); strings.EqualFold(lang, tag) {
+			return locale, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的语言%q", tag)
+}
+
+type localeKey struct{}
+
+// WithLocale 返回指定本次请求语言的 ctx，优先于 Catalog 的默认语言
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFrom 取出 WithLocale 指定的语言
+func LocaleFrom(ctx context.Context) (Locale, bool) {
+	locale, ok := ctx.Value(localeKey{}).(Locale)
+	return locale, ok
+}
+
+// Catalog 按错误码或消息键查找文案并替换参数
+// 查找顺序：ctx 指定的语言、Catalog 的默认语言；都没有时错误使用 DomainError.Message，消息返回键本身
+type Catalog struct {
+	locale Locale
+}
+
+// NewCatalog 以配置的语言（如 en-US）为默认语言，为空时使用 DefaultLocale
+func NewCatalog(tag string) (*Catalog, error) {
+	locale, err := ParseLocale(tag)
+	if err != nil {
+		return nil, err
+	}
+	return &Catalog{locale: locale}, nil
+}
+
+// Default 使用 DefaultLocale 的 Catalog，用于读取配置之前
+func Default() *Catalog {
+	return &Catalog{locale: DefaultLocale}
+}
+
+// Locale 本次请求使用的语言
+func (c *Catalog) Locale(ctx context.Context) Locale {
+	if locale, ok := LocaleFrom(ctx); ok {
+		if _, supported := bundles[locale]; supported {
+			return locale
+		}
+	}
+	return c.locale
+}
+
+// Message 键为 key 的文案，如 Message(ctx, "log.create_user_failed", Params{"error": ...})
+func (c *Catalog) Message(ctx context.Context, key string, params Params) string {
+	if template, ok := c.lookup(ctx, key); ok {
+		return render(template, params)
+	}
+	return key
+}
+
+var _ repositories.Logger = (*Catalog)(nil)
+
+// Log 输出键为 key 的文案，error 类型的参数按 Error 的文案替换
+func (c *Catalog) Log(ctx context.Context, key string, params Params) {
+	rendered := make(Params, len(params))
+	for name, value := range params {
+		if err, ok := value.(error); ok {
+			value = c.Error(ctx, err)
+		}
+		rendered[name] = value
+	}
+	log.Print(c.Message(ctx, key, rendered))
+}
+
+// Error 面向用户的错误说明：按错误链中最外层领域错误的错误码查找文案，参数取自 Details，不包含原因
+// 详情中有 reason 或 kind 时优先使用 "错误码.取值" 的文案，如 INVALID_EMAIL.disposable
+// 不是领域错误时原样返回 err.Error()
+func (c *Catalog) Error(ctx context.Context, err error) string {
+	if err == nil {
+		return ""
+	}
+	domainErr, ok := models.AsDomainError(err)
+	if !ok {
+		return err.Error()
+	}
+	for _, key := range errorKeys(domainErr) {
+		if template, ok := c.lookup(ctx, key); ok {
+			return render(template, domainErr.Details)
+		}
+	}
+	return domainErr.Message
+}
+
+// variantDetails 用于选择同一错误码下不同文案的详情
+var variantDetails = []string{"reason", "kind"}
+
+func errorKeys(err *models.DomainError) []string {
+	code := string(err.Code)
+	keys := make([]string, 0, len(variantDetails)+1)
+	for _, name := range variantDetails {
+		if value, ok := err.Details[name]; ok {
+			keys = append(keys, fmt.Sprintf("%s.%v", code, value))
+		}
+	}
+	return append(keys, code)
+}
+
+func (c *Catalog) lookup(ctx context.Context, key string) (string, bool) {
+	for _, locale := range []Locale{c.Locale(ctx), c.locale} {
+		if template, ok := bundles[locale][key]; ok {
+			return template, true
+		}
+	}
+	return "", false
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// render 替换 {name} 占位符，缺少的参数保留原样
+func render(template string, params map[string]any) string {
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		if value, ok := params[match[1:len(match)-1]]; ok {
+			return fmt.Sprint(value)
+		}
+		return match
+	})
+}
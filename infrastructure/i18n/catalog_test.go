@@ -0,0 +1,156 @@
+package i18n_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadKeys(t *testing.T, locale i18n.Locale) []string {
+	data, err := os.ReadFile(fmt.Sprintf("locales/%s.json", locale))
+	assert.NoError(t, err)
+	var bundle map[string]string
+	assert.NoError(t, json.Unmarshal(data, &bundle))
+	keys := make([]string, 0, len(bundle))
+	for key := range bundle {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestBundles(t *testing.T) {
+	t.Run("各语言的键相同", func(t *testing.T) {
+		assert.Equal(t, loadKeys(t, i18n.ZhCN), loadKeys(t, i18n.EnUS))
+	})
+
+	t.Run("每个错误码都有英文文案", func(t *testing.T) {
+		catalog, err := i18n.NewCatalog("en-US")
+		assert.NoError(t, err)
+		for _, domainErr := range []*models.DomainError{
+			models.ErrInternal, models.ErrNotEnabled, models.ErrInvalidArgument,
+			models.ErrUserNotFound, models.ErrUserNotActive, models.ErrUserErased, models.ErrInvalidUserName,
+			models.ErrInvalidStatusChange, models.ErrInsufficientConsumption, models.ErrInvalidEmail,
+			models.ErrEmailTaken, models.ErrSameEmail, models.ErrEmailChangeTokenNotFound,
+			models.ErrEmailChangeExpired, models.ErrEmailChangeConfirmed, models.ErrEmailChangeMismatch,
+			models.ErrOrderNotFound, models.ErrOrderInactive, models.ErrInvalidTransition, models.ErrInvalidAmount,
+			models.ErrInvalidOrderItems, models.ErrCurrencyMismatch, models.ErrRefundExceedsPaid,
+			models.ErrInvalidRefundReason, models.ErrLimitExceeded, models.ErrInvalidLimit,
+			models.ErrProductNotFound, models.ErrSKUTaken, models.ErrInvalidProduct, models.ErrInvalidQuantity,
+			models.ErrOutOfStock, models.ErrUnsupportedCurrency, models.ErrInvalidRate,
+			models.ErrExchangeRateNotFound, models.ErrMoneyOverflow, models.ErrMoneyFormat,
+			models.ErrInvalidTier, models.ErrTierThresholdTaken,
+			repositories.ErrorNotFound, repositories.ErrorInvalid, repositories.ErrorVersionConflict,
+			repositories.ErrorNoTransaction, repositories.ErrorLocked, repositories.ErrorDuplicate,
+		} {
+			assert.NotEqual(t, domainErr.Message, catalog.Error(context.Background(), domainErr), domainErr.Code)
+		}
+	})
+}
+
+func TestParseLocale(t *testing.T) {
+	for tag, want := range map[string]i18n.Locale{
+		"":      i18n.DefaultLocale,
+		"en-US": i18n.EnUS,
+		"en_us": i18n.EnUS,
+		"en":    i18n.EnUS,
+		"ZH-cn": i18n.ZhCN,
+		"zh":    i18n.ZhCN,
+	} {
+		locale, err := i18n.ParseLocale(tag)
+		assert.NoError(t, err, tag)
+		assert.Equal(t, want, locale, tag)
+	}
+
+	_, err := i18n.ParseLocale("fr-FR")
+	assert.Error(t, err)
+	_, err = i18n.NewCatalog("fr-FR")
+	assert.Error(t, err)
+}
+
+func TestCatalog_Error(t *testing.T) {
+	ctx := context.Background()
+	zh := i18n.Default()
+	en, err := i18n.NewCatalog("en-US")
+	assert.NoError(t, err)
+
+	t.Run("按错误码替换详情参数", func(t *testing.T) {
+		err := fmt.Errorf("查询失败: %w", models.ErrUserNotFound.With("user_id", uint64(3)).Wrap(repositories.ErrorNotFound))
+		assert.Equal(t, "用户3不存在", zh.Error(ctx, err))
+		assert.Equal(t, "User 3 not found", en.Error(ctx, err))
+	})
+
+	t.Run("ctx 指定的语言优先", func(t *testing.T) {
+		err := models.ErrSKUTaken.With("sku", "A-1")
+		assert.Equal(t, "SKU A-1 already exists", zh.Error(i18n.WithLocale(ctx, i18n.EnUS), err))
+		assert.Equal(t, "SKU已存在", models.ErrSKUTaken.Message)
+		assert.Equal(t, "SKUA-1已存在", en.Error(i18n.WithLocale(ctx, i18n.ZhCN), err))
+		// 不支持的语言使用默认语言
+		assert.Equal(t, "SKUA-1已存在", zh.Error(i18n.WithLocale(ctx, "fr-FR"), err))
+	})
+
+	t.Run("按 reason 或 kind 选择文案", func(t *testing.T) {
+		err := models.ErrInvalidEmail.With("reason", models.EmailReasonDisposable).With("domain", "yopmail.com")
+		assert.Equal(t, "Disposable email domain yopmail.com is not allowed", en.Error(ctx, err))
+
+		var limitErr error = &models.LimitExceededError{Kind: models.LimitSingleOrder,
+			Limit: models.MustParseMoney("1000"), Requested: models.MustParseMoney("1500")}
+		assert.Equal(t, "单笔订单金额1500.00超过上限1000.00", zh.Error(ctx, limitErr))
+		assert.Equal(t, "Order amount 1500.00 exceeds the limit of 1000.00", en.Error(ctx, limitErr))
+	})
+
+	t.Run("缺少的参数保留占位符", func(t *testing.T) {
+		assert.Equal(t, "Order {order_id} not found", en.Error(ctx, models.ErrOrderNotFound))
+	})
+
+	t.Run("没有文案的错误码使用默认说明", func(t *testing.T) {
+		err := models.NewDomainError("UNKNOWN", models.CategoryInternal, "未知错误")
+		assert.Equal(t, "未知错误", en.Error(ctx, err))
+	})
+
+	t.Run("非领域错误原样返回", func(t *testing.T) {
+		assert.Equal(t, "connection refused", en.Error(ctx, errors.New("connection refused")))
+		assert.Empty(t, en.Error(ctx, nil))
+	})
+}
+
+func TestCatalog_Message(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), i18n.EnUS)
+	catalog := i18n.Default()
+
+	assert.Equal(t, "Failed to create user: Email a@qq.com is already registered",
+		catalog.Message(ctx, "log.create_user_failed", i18n.Params{
+			"error": catalog.Error(ctx, models.ErrEmailTaken.With("email", "a@qq.com")),
+		}))
+	assert.Equal(t, "用户7消费总额变化: -10.00", catalog.Message(context.Background(), "log.consumption_changed",
+		i18n.Params{"user_id": 7, "delta": models.MustParseMoney("-10")}))
+	assert.Equal(t, "log.unknown", catalog.Message(ctx, "log.unknown", nil))
+}
+
+func TestCatalog_Log(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+	catalog, err := i18n.NewCatalog("en-US")
+	assert.NoError(t, err)
+
+	// error 参数按错误码的文案输出
+	catalog.Log(context.Background(), "log.outbox_parked", i18n.Params{
+		"message_id": 7, "attempts": 10, "error": models.ErrOrderNotFound.With("order_id", 3)})
+	assert.Equal(t, "Outbox message 7 parked after 10 failed attempts: Order 3 not found\n", buf.String())
+}
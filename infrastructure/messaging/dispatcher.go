@@ -0,0 +1,99 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/i18n"
+)
+
+// EventHandler 领域事件的订阅者
+type EventHandler func(ctx context.Context, event models.DomainEvent) error
+
+// Dispatcher 进程内的领域事件分发器
+//   - 同步订阅者按订阅顺序在 Dispatch 中依次执行，错误与 panic 合并后返回
+//   - 异步订阅者各自在新的 goroutine 中执行，不保证顺序，错误与 panic 只记录日志；
+//     收到的 ctx 不随调用方取消
+type Dispatcher struct {
+	mu     sync.RWMutex
+	sync   map[string][]EventHandler
+	async  map[string][]EventHandler
+	wg     sync.WaitGroup
+	logger repositories.Logger
+}
+
+// NewDispatcher 异步订阅者的错误按默认语言记录日志
+func NewDispatcher() *Dispatcher {
+	return NewDispatcherWithLogger(i18n.Default())
+}
+
+// NewDispatcherWithLogger 异步订阅者的错误通过 logger 记录，如配置了语言的 i18n.Catalog
+func NewDispatcherWithLogger(logger repositories.Logger) *Dispatcher {
+	return &Dispatcher{
+		sync:   make(map[string][]EventHandler),
+		async:  make(map[string][]EventHandler),
+		logger: logger,
+	}
+}
+
+var _ repositories.EventDispatcher = (*Dispatcher)(nil)
+
+// Subscribe 订阅 eventName 事件（如 models.EventOrderCreated），在分发时同步执行
+func (d *Dispatcher) Subscribe(eventName string, handler EventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sync[eventName] = append(d.sync[eventName], handler)
+}
+
+// SubscribeAsync 订阅 eventName 事件，在后台执行
+func (d *Dispatcher) SubscribeAsync(eventName string, handler EventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.async[eventName] = append(d.async[eventName], handler)
+}
+
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...models.DomainEvent) error {
+	var errs []error
+	for _, event := range events {
+		d.mu.RLock()
+		syncHandlers := d.sync[event.EventName()]
+		asyncHandlers := d.async[event.EventName()]
+		d.mu.RUnlock()
+
+		for _, handler := range asyncHandlers {
+			d.wg.Add(1)
+			go func(handler EventHandler, event models.DomainEvent) {
+				defer d.wg.Done()
+				asyncCtx := context.WithoutCancel(ctx)
+				if err := invoke(asyncCtx, handler, event); err != nil {
+					d.logger.Log(asyncCtx, "log.async_event_failed", map[string]any{"event": event.EventName(), "error": err})
+				}
+			}(handler, event)
+		}
+		for _, handler := range syncHandlers {
+			if err := invoke(ctx, handler, event); err != nil {
+				errs = append(errs, fmt.Errorf("处理事件%s失败: %w", event.EventName(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Wait 等待已开始的异步处理全部完成，用于进程退出前
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// invoke 执行订阅者，把 panic 转为错误，避免一个订阅者影响其他订阅者
+func invoke(ctx context.Context, handler EventHandler, event models.DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
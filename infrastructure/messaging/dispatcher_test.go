@@ -0,0 +1,95 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_Sync(t *testing.T) {
+	dispatcher := messaging.NewDispatcher()
+	var got []string
+	dispatcher.Subscribe(models.EventUserRegistered, func(ctx context.Context, event models.DomainEvent) error {
+		got = append(got, "first")
+		return nil
+	})
+	dispatcher.Subscribe(models.EventUserRegistered, func(ctx context.Context, event models.DomainEvent) error {
+		got = append(got, "second")
+		return nil
+	})
+	dispatcher.Subscribe(models.EventConsumptionChanged, func(ctx context.Context, event models.DomainEvent) error {
+		got = append(got, "consumption")
+		return nil
+	})
+
+	err := dispatcher.Dispatch(context.Background(), models.UserRegistered{UserID: 1}, models.ConsumptionChanged{UserID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "consumption"}, got)
+
+	// 没有订阅者的事件直接忽略
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), models.OrderCreated{}))
+}
+
+func TestDispatcher_SyncErrors(t *testing.T) {
+	dispatcher := messaging.NewDispatcher()
+	errHandler := errors.New("handler failed")
+	called := false
+	dispatcher.Subscribe(models.EventOrderCreated, func(ctx context.Context, event models.DomainEvent) error {
+		return errHandler
+	})
+	dispatcher.Subscribe(models.EventOrderCreated, func(ctx context.Context, event models.DomainEvent) error {
+		panic("boom")
+	})
+	dispatcher.Subscribe(models.EventOrderCreated, func(ctx context.Context, event models.DomainEvent) error {
+		called = true
+		return nil
+	})
+
+	err := dispatcher.Dispatch(context.Background(), models.OrderCreated{})
+	assert.ErrorIs(t, err, errHandler)
+	assert.ErrorContains(t, err, "boom")
+	assert.True(t, called, "一个订阅者失败不影响其他订阅者")
+}
+
+// recordingLogger 记录日志的文案键
+type recordingLogger struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (l *recordingLogger) Log(_ context.Context, key string, _ map[string]any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keys = append(l.keys, key)
+}
+
+func TestDispatcher_Async(t *testing.T) {
+	logger := &recordingLogger{}
+	dispatcher := messaging.NewDispatcherWithLogger(logger)
+	var mu sync.Mutex
+	var got []uint64
+	dispatcher.SubscribeAsync(models.EventUserRegistered, func(ctx context.Context, event models.DomainEvent) error {
+		assert.NoError(t, ctx.Err(), "调用方取消后异步订阅者仍可使用 ctx")
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, event.(models.UserRegistered).UserID)
+		return nil
+	})
+	dispatcher.SubscribeAsync(models.EventUserRegistered, func(ctx context.Context, event models.DomainEvent) error {
+		panic("async panic")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := dispatcher.Dispatch(ctx, models.UserRegistered{UserID: 1}, models.UserRegistered{UserID: 2})
+	cancel()
+	assert.NoError(t, err, "异步订阅者的错误不返回给调用方")
+
+	dispatcher.Wait()
+	assert.ElementsMatch(t, []uint64{1, 2}, got)
+	assert.Equal(t, []string{"log.async_event_failed", "log.async_event_failed"}, logger.keys)
+}
@@ -0,0 +1,35 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+)
+
+// MemoryPublisher 把消息保存在内存中，用于本地调试与测试
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []models.OutboxMessage
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+var _ repositories.Publisher = (*MemoryPublisher)(nil)
+
+func (p *MemoryPublisher) Publish(ctx context.Context, msg *models.OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, *msg)
+	return nil
+}
+
+// Messages 返回已发布消息的副本（按发布顺序）
+func (p *MemoryPublisher) Messages() []models.OutboxMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]models.OutboxMessage(nil), p.messages...)
+}
@@ -0,0 +1,64 @@
+package messaging_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/messaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryPublisher_Publish(t *testing.T) {
+	publisher := messaging.NewMemoryPublisher()
+	ctx := context.Background()
+
+	msg, err := models.NewOutboxMessage(models.AggregateOrder, 1001, models.EventOrderCreated,
+		models.OrderEvent{OrderID: 1001, UserID: 1, Amount: models.MustParseMoney("100")})
+	assert.NoError(t, err)
+	msg.ID = 1
+
+	assert.NoError(t, publisher.Publish(ctx, msg))
+	msg.ID = 2
+	assert.NoError(t, publisher.Publish(ctx, msg))
+
+	messages := publisher.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, uint64(1), messages[0].ID)
+	assert.Equal(t, uint64(2), messages[1].ID)
+}
+
+func TestFilePublisher_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	publisher := messaging.NewFilePublisher(path)
+	ctx := context.Background()
+
+	for i := uint64(1); i <= 2; i++ {
+		msg, err := models.NewOutboxMessage(models.AggregateOrder, 1000+i, models.EventOrderCreated,
+			models.OrderEvent{OrderID: 1000 + i, UserID: 1, Amount: models.MustParseMoney("100")})
+		assert.NoError(t, err)
+		msg.ID = i
+		assert.NoError(t, publisher.Publish(ctx, msg))
+	}
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	assert.Len(t, lines, 2)
+	assert.Equal(t, models.EventOrderCreated, lines[0]["event_type"])
+	assert.Equal(t, float64(1002), lines[1]["aggregate_id"])
+	// payload 以JSON对象写入而非字符串
+	assert.Equal(t, float64(1002), lines[1]["payload"].(map[string]interface{})["order_id"])
+}
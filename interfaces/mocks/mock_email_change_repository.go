@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: domain/repositories/email_change_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEmailChangeRepository is a mock of EmailChangeRepository interface.
+type MockEmailChangeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailChangeRepositoryMockRecorder
+}
+
+// MockEmailChangeRepositoryMockRecorder is the mock recorder for MockEmailChangeRepository.
+type MockEmailChangeRepositoryMockRecorder struct {
+	mock *MockEmailChangeRepository
+}
+
+// NewMockEmailChangeRepository creates a new mock instance.
+func NewMockEmailChangeRepository(ctrl *gomock.Controller) *MockEmailChangeRepository {
+	mock := &MockEmailChangeRepository{ctrl: ctrl}
+	mock.recorder = &MockEmailChangeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailChangeRepository) EXPECT() *MockEmailChangeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CancelPending mocks base method.
+func (m *MockEmailChangeRepository) CancelPending(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPending", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPending indicates an expected call of CancelPending.
+func (mr *MockEmailChangeRepositoryMockRecorder) CancelPending(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPending", reflect.TypeOf((*MockEmailChangeRepository)(nil).CancelPending), ctx, userID)
+}
+
+// DeleteByUserID mocks base method.
+func (m *MockEmailChangeRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUserID", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByUserID indicates an expected call of DeleteByUserID.
+func (mr *MockEmailChangeRepositoryMockRecorder) DeleteByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUserID", reflect.TypeOf((*MockEmailChangeRepository)(nil).DeleteByUserID), ctx, userID)
+}
+
+// FindByTokenHash mocks base method.
+func (m *MockEmailChangeRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.EmailChangeRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.EmailChangeRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByTokenHash indicates an expected call of FindByTokenHash.
+func (mr *MockEmailChangeRepositoryMockRecorder) FindByTokenHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTokenHash", reflect.TypeOf((*MockEmailChangeRepository)(nil).FindByTokenHash), ctx, tokenHash)
+}
+
+// MarkConfirmed mocks base method.
+func (m *MockEmailChangeRepository) MarkConfirmed(ctx context.Context, req *models.EmailChangeRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkConfirmed", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkConfirmed indicates an expected call of MarkConfirmed.
+func (mr *MockEmailChangeRepositoryMockRecorder) MarkConfirmed(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkConfirmed", reflect.TypeOf((*MockEmailChangeRepository)(nil).MarkConfirmed), ctx, req)
+}
+
+// Save mocks base method.
+func (m *MockEmailChangeRepository) Save(ctx context.Context, req *models.EmailChangeRequest) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, req)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockEmailChangeRepositoryMockRecorder) Save(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockEmailChangeRepository)(nil).Save), ctx, req)
+}
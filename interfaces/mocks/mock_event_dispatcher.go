@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: domain/repositories/event_dispatcher.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockEventDispatcher is a mock of EventDispatcher interface.
+type MockEventDispatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventDispatcherMockRecorder
+}
+
+// MockEventDispatcherMockRecorder is the mock recorder for MockEventDispatcher.
+type MockEventDispatcherMockRecorder struct {
+	mock *MockEventDispatcher
+}
+
+// NewMockEventDispatcher creates a new mock instance.
+func NewMockEventDispatcher(ctrl *gomock.Controller) *MockEventDispatcher {
+	mock := &MockEventDispatcher{ctrl: ctrl}
+	mock.recorder = &MockEventDispatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventDispatcher) EXPECT() *MockEventDispatcherMockRecorder {
+	return m.recorder
+}
+
+// Dispatch mocks base method.
+func (m *MockEventDispatcher) Dispatch(ctx context.Context, events ...models.DomainEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Dispatch", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockEventDispatcherMockRecorder) Dispatch(ctx interface{}, events ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockEventDispatcher)(nil).Dispatch), varargs...)
+}
@@ -1,72 +1,143 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: domain/repositories/order_repository.go
 
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
 	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	repositories "github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 	gomock "github.com/golang/mock/gomock"
-	reflect "reflect"
 )
 
-// MockOrderRepository is a mock of OrderRepository interface
+// MockOrderRepository is a mock of OrderRepository interface.
 type MockOrderRepository struct {
 	ctrl     *gomock.Controller
 	recorder *MockOrderRepositoryMockRecorder
 }
 
-// MockOrderRepositoryMockRecorder is the mock recorder for MockOrderRepository
+// MockOrderRepositoryMockRecorder is the mock recorder for MockOrderRepository.
 type MockOrderRepositoryMockRecorder struct {
 	mock *MockOrderRepository
 }
 
-// NewMockOrderRepository creates a new mock instance
+// NewMockOrderRepository creates a new mock instance.
 func NewMockOrderRepository(ctrl *gomock.Controller) *MockOrderRepository {
 	mock := &MockOrderRepository{ctrl: ctrl}
 	mock.recorder = &MockOrderRepositoryMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
-func (_m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
-	return _m.recorder
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
+	return m.recorder
 }
 
-// FindByID mocks base method
-func (_m *MockOrderRepository) FindByID(orderID uint64) (*models.Order, error) {
-	ret := _m.ctrl.Call(_m, "FindByID", orderID)
+// FindByID mocks base method.
+func (m *MockOrderRepository) FindByID(ctx context.Context, orderID uint64) (*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, orderID)
 	ret0, _ := ret[0].(*models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindByID indicates an expected call of FindByID
-func (_mr *MockOrderRepositoryMockRecorder) FindByID(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "FindByID", reflect.TypeOf((*MockOrderRepository)(nil).FindByID), arg0)
+// FindByID indicates an expected call of FindByID.
+func (mr *MockOrderRepositoryMockRecorder) FindByID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockOrderRepository)(nil).FindByID), ctx, orderID)
+}
+
+// FindByIDForUpdate mocks base method.
+func (m *MockOrderRepository) FindByIDForUpdate(ctx context.Context, orderID uint64, mode repositories.LockMode) (*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, orderID, mode)
+	ret0, _ := ret[0].(*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Save mocks base method
-func (_m *MockOrderRepository) Save(order *models.Order) (uint64, error) {
-	ret := _m.ctrl.Call(_m, "Save", order)
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockOrderRepositoryMockRecorder) FindByIDForUpdate(ctx, orderID, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockOrderRepository)(nil).FindByIDForUpdate), ctx, orderID, mode)
+}
+
+// FindByUserID mocks base method.
+func (m *MockOrderRepository) FindByUserID(ctx context.Context, userID uint64) ([]*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserID indicates an expected call of FindByUserID.
+func (mr *MockOrderRepositoryMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockOrderRepository)(nil).FindByUserID), ctx, userID)
+}
+
+// FindByUserIDSince mocks base method.
+func (m *MockOrderRepository) FindByUserIDSince(ctx context.Context, userID uint64, since time.Time) ([]*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserIDSince", ctx, userID, since)
+	ret0, _ := ret[0].([]*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserIDSince indicates an expected call of FindByUserIDSince.
+func (mr *MockOrderRepositoryMockRecorder) FindByUserIDSince(ctx, userID, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserIDSince", reflect.TypeOf((*MockOrderRepository)(nil).FindByUserIDSince), ctx, userID, since)
+}
+
+// Save mocks base method.
+func (m *MockOrderRepository) Save(ctx context.Context, order *models.Order) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, order)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Save indicates an expected call of Save
-func (_mr *MockOrderRepositoryMockRecorder) Save(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Save", reflect.TypeOf((*MockOrderRepository)(nil).Save), arg0)
+// Save indicates an expected call of Save.
+func (mr *MockOrderRepositoryMockRecorder) Save(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderRepository)(nil).Save), ctx, order)
+}
+
+// UpdateRefund mocks base method.
+func (m *MockOrderRepository) UpdateRefund(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRefund", ctx, order, from)
+	ret0, _ := ret[0].(int8)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRefund indicates an expected call of UpdateRefund.
+func (mr *MockOrderRepositoryMockRecorder) UpdateRefund(ctx, order, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRefund", reflect.TypeOf((*MockOrderRepository)(nil).UpdateRefund), ctx, order, from)
 }
 
-// UpdateValidity mocks base method
-func (_m *MockOrderRepository) UpdateValidity(orderID uint64, isValid bool) (int8, error) {
-	ret := _m.ctrl.Call(_m, "UpdateValidity", orderID, isValid)
+// UpdateStatus mocks base method.
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, order *models.Order, from models.OrderStatus) (int8, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, order, from)
 	ret0, _ := ret[0].(int8)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateValidity indicates an expected call of UpdateValidity
-func (_mr *MockOrderRepositoryMockRecorder) UpdateValidity(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateValidity", reflect.TypeOf((*MockOrderRepository)(nil).UpdateValidity), arg0, arg1)
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(ctx, order, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), ctx, order, from)
 }
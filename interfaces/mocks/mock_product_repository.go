@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: domain/repositories/product_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockProductRepository is a mock of ProductRepository interface.
+type MockProductRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductRepositoryMockRecorder
+}
+
+// MockProductRepositoryMockRecorder is the mock recorder for MockProductRepository.
+type MockProductRepositoryMockRecorder struct {
+	mock *MockProductRepository
+}
+
+// NewMockProductRepository creates a new mock instance.
+func NewMockProductRepository(ctrl *gomock.Controller) *MockProductRepository {
+	mock := &MockProductRepository{ctrl: ctrl}
+	mock.recorder = &MockProductRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DecrementStock mocks base method.
+func (m *MockProductRepository) DecrementStock(ctx context.Context, productID uint64, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementStock", ctx, productID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementStock indicates an expected call of DecrementStock.
+func (mr *MockProductRepositoryMockRecorder) DecrementStock(ctx, productID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementStock", reflect.TypeOf((*MockProductRepository)(nil).DecrementStock), ctx, productID, quantity)
+}
+
+// FindByID mocks base method.
+func (m *MockProductRepository) FindByID(ctx context.Context, id uint64) (*models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockProductRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockProductRepository)(nil).FindByID), ctx, id)
+}
+
+// FindBySKU mocks base method.
+func (m *MockProductRepository) FindBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBySKU", ctx, sku)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBySKU indicates an expected call of FindBySKU.
+func (mr *MockProductRepositoryMockRecorder) FindBySKU(ctx, sku interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySKU", reflect.TypeOf((*MockProductRepository)(nil).FindBySKU), ctx, sku)
+}
+
+// FindLowStock mocks base method.
+func (m *MockProductRepository) FindLowStock(ctx context.Context, threshold int) ([]*models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLowStock", ctx, threshold)
+	ret0, _ := ret[0].([]*models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLowStock indicates an expected call of FindLowStock.
+func (mr *MockProductRepositoryMockRecorder) FindLowStock(ctx, threshold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLowStock", reflect.TypeOf((*MockProductRepository)(nil).FindLowStock), ctx, threshold)
+}
+
+// IncrementStock mocks base method.
+func (m *MockProductRepository) IncrementStock(ctx context.Context, productID uint64, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementStock", ctx, productID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementStock indicates an expected call of IncrementStock.
+func (mr *MockProductRepositoryMockRecorder) IncrementStock(ctx, productID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementStock", reflect.TypeOf((*MockProductRepository)(nil).IncrementStock), ctx, productID, quantity)
+}
+
+// Save mocks base method.
+func (m *MockProductRepository) Save(ctx context.Context, product *models.Product) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, product)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockProductRepositoryMockRecorder) Save(ctx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockProductRepository)(nil).Save), ctx, product)
+}
+
+// MockOrderItemRepository is a mock of OrderItemRepository interface.
+type MockOrderItemRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderItemRepositoryMockRecorder
+}
+
+// MockOrderItemRepositoryMockRecorder is the mock recorder for MockOrderItemRepository.
+type MockOrderItemRepositoryMockRecorder struct {
+	mock *MockOrderItemRepository
+}
+
+// NewMockOrderItemRepository creates a new mock instance.
+func NewMockOrderItemRepository(ctrl *gomock.Controller) *MockOrderItemRepository {
+	mock := &MockOrderItemRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderItemRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderItemRepository) EXPECT() *MockOrderItemRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByOrderID mocks base method.
+func (m *MockOrderItemRepository) FindByOrderID(ctx context.Context, orderID uint64) ([]models.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]models.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByOrderID indicates an expected call of FindByOrderID.
+func (mr *MockOrderItemRepositoryMockRecorder) FindByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByOrderID", reflect.TypeOf((*MockOrderItemRepository)(nil).FindByOrderID), ctx, orderID)
+}
+
+// Save mocks base method.
+func (m *MockOrderItemRepository) Save(ctx context.Context, items []models.OrderItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOrderItemRepositoryMockRecorder) Save(ctx, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderItemRepository)(nil).Save), ctx, items)
+}
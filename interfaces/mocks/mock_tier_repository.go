@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: domain/repositories/tier_repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTierRepository is a mock of TierRepository interface.
+type MockTierRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTierRepositoryMockRecorder
+}
+
+// MockTierRepositoryMockRecorder is the mock recorder for MockTierRepository.
+type MockTierRepositoryMockRecorder struct {
+	mock *MockTierRepository
+}
+
+// NewMockTierRepository creates a new mock instance.
+func NewMockTierRepository(ctrl *gomock.Controller) *MockTierRepository {
+	mock := &MockTierRepository{ctrl: ctrl}
+	mock.recorder = &MockTierRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTierRepository) EXPECT() *MockTierRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindChangesByUserID mocks base method.
+func (m *MockTierRepository) FindChangesByUserID(ctx context.Context, userID uint64) ([]*models.TierChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindChangesByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*models.TierChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindChangesByUserID indicates an expected call of FindChangesByUserID.
+func (mr *MockTierRepositoryMockRecorder) FindChangesByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindChangesByUserID", reflect.TypeOf((*MockTierRepository)(nil).FindChangesByUserID), ctx, userID)
+}
+
+// FindThresholds mocks base method.
+func (m *MockTierRepository) FindThresholds(ctx context.Context) (models.TierSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindThresholds", ctx)
+	ret0, _ := ret[0].(models.TierSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindThresholds indicates an expected call of FindThresholds.
+func (mr *MockTierRepositoryMockRecorder) FindThresholds(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindThresholds", reflect.TypeOf((*MockTierRepository)(nil).FindThresholds), ctx)
+}
+
+// SaveChange mocks base method.
+func (m *MockTierRepository) SaveChange(ctx context.Context, change *models.TierChange) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChange", ctx, change)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveChange indicates an expected call of SaveChange.
+func (mr *MockTierRepositoryMockRecorder) SaveChange(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChange", reflect.TypeOf((*MockTierRepository)(nil).SaveChange), ctx, change)
+}
+
+// SaveThreshold mocks base method.
+func (m *MockTierRepository) SaveThreshold(ctx context.Context, threshold *models.TierThreshold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveThreshold", ctx, threshold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveThreshold indicates an expected call of SaveThreshold.
+func (mr *MockTierRepositoryMockRecorder) SaveThreshold(ctx, threshold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveThreshold", reflect.TypeOf((*MockTierRepository)(nil).SaveThreshold), ctx, threshold)
+}
@@ -1,44 +1,90 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: domain/repositories/transaction_repository.go
 
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
-	gomock "github.com/golang/mock/gomock"
+	context "context"
 	reflect "reflect"
+
+	repositories "github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
+	gomock "github.com/golang/mock/gomock"
 )
 
-// MockTransactionManager is a mock of TransactionManager interface
+// MockTransactionManager is a mock of TransactionManager interface.
 type MockTransactionManager struct {
 	ctrl     *gomock.Controller
 	recorder *MockTransactionManagerMockRecorder
 }
 
-// MockTransactionManagerMockRecorder is the mock recorder for MockTransactionManager
+// MockTransactionManagerMockRecorder is the mock recorder for MockTransactionManager.
 type MockTransactionManagerMockRecorder struct {
 	mock *MockTransactionManager
 }
 
-// NewMockTransactionManager creates a new mock instance
+// NewMockTransactionManager creates a new mock instance.
 func NewMockTransactionManager(ctrl *gomock.Controller) *MockTransactionManager {
 	mock := &MockTransactionManager{ctrl: ctrl}
 	mock.recorder = &MockTransactionManagerMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
-func (_m *MockTransactionManager) EXPECT() *MockTransactionManagerMockRecorder {
-	return _m.recorder
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionManager) EXPECT() *MockTransactionManagerMockRecorder {
+	return m.recorder
+}
+
+// AfterCommit mocks base method.
+func (m *MockTransactionManager) AfterCommit(ctx context.Context, fn func(context.Context)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AfterCommit", ctx, fn)
+}
+
+// AfterCommit indicates an expected call of AfterCommit.
+func (mr *MockTransactionManagerMockRecorder) AfterCommit(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AfterCommit", reflect.TypeOf((*MockTransactionManager)(nil).AfterCommit), ctx, fn)
+}
+
+// Transaction mocks base method.
+func (m *MockTransactionManager) Transaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockTransactionManagerMockRecorder) Transaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockTransactionManager)(nil).Transaction), ctx, fn)
+}
+
+// TransactionDepth mocks base method.
+func (m *MockTransactionManager) TransactionDepth(ctx context.Context) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransactionDepth", ctx)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// TransactionDepth indicates an expected call of TransactionDepth.
+func (mr *MockTransactionManagerMockRecorder) TransactionDepth(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionDepth", reflect.TypeOf((*MockTransactionManager)(nil).TransactionDepth), ctx)
 }
 
-// Transaction mocks base method
-func (_m *MockTransactionManager) Transaction(_param0 func() error) error {
-	ret := _m.ctrl.Call(_m, "Transaction", _param0)
+// TransactionWithOptions mocks base method.
+func (m *MockTransactionManager) TransactionWithOptions(ctx context.Context, opts repositories.TxOptions, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransactionWithOptions", ctx, opts, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Transaction indicates an expected call of Transaction
-func (_mr *MockTransactionManagerMockRecorder) Transaction(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Transaction", reflect.TypeOf((*MockTransactionManager)(nil).Transaction), arg0)
+// TransactionWithOptions indicates an expected call of TransactionWithOptions.
+func (mr *MockTransactionManagerMockRecorder) TransactionWithOptions(ctx, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionWithOptions", reflect.TypeOf((*MockTransactionManager)(nil).TransactionWithOptions), ctx, opts, fn)
 }
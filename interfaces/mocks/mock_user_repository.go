@@ -1,85 +1,147 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: domain/repositories/user_repository.go
 
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
+	reflect "reflect"
+
 	models "github.com/NorioKe/mysql_demo_use_gorm/domain/models"
+	repositories "github.com/NorioKe/mysql_demo_use_gorm/domain/repositories"
 	gomock "github.com/golang/mock/gomock"
-	reflect "reflect"
 )
 
-// MockUserRepository is a mock of UserRepository interface
+// MockUserRepository is a mock of UserRepository interface.
 type MockUserRepository struct {
 	ctrl     *gomock.Controller
 	recorder *MockUserRepositoryMockRecorder
 }
 
-// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository
+// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository.
 type MockUserRepositoryMockRecorder struct {
 	mock *MockUserRepository
 }
 
-// NewMockUserRepository creates a new mock instance
+// NewMockUserRepository creates a new mock instance.
 func NewMockUserRepository(ctrl *gomock.Controller) *MockUserRepository {
 	mock := &MockUserRepository{ctrl: ctrl}
 	mock.recorder = &MockUserRepositoryMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
-func (_m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
-	return _m.recorder
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddTotalConsumption mocks base method.
+func (m *MockUserRepository) AddTotalConsumption(ctx context.Context, userID uint64, delta models.Money) (int8, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTotalConsumption", ctx, userID, delta)
+	ret0, _ := ret[0].(int8)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTotalConsumption indicates an expected call of AddTotalConsumption.
+func (mr *MockUserRepositoryMockRecorder) AddTotalConsumption(ctx, userID, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTotalConsumption", reflect.TypeOf((*MockUserRepository)(nil).AddTotalConsumption), ctx, userID, delta)
 }
 
-// FindByID mocks base method
-func (_m *MockUserRepository) FindByID(id uint64) (*models.User, error) {
-	ret := _m.ctrl.Call(_m, "FindByID", id)
+// FindByEmail mocks base method.
+func (m *MockUserRepository) FindByEmail(ctx context.Context, canonicalEmail string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", ctx, canonicalEmail)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindByID indicates an expected call of FindByID
-func (_mr *MockUserRepositoryMockRecorder) FindByID(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "FindByID", reflect.TypeOf((*MockUserRepository)(nil).FindByID), arg0)
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockUserRepositoryMockRecorder) FindByEmail(ctx, canonicalEmail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindByEmail), ctx, canonicalEmail)
 }
 
-// FindByEmail mocks base method
-func (_m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
-	ret := _m.ctrl.Call(_m, "FindByEmail", email)
+// FindByID mocks base method.
+func (m *MockUserRepository) FindByID(ctx context.Context, id uint64) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindByEmail indicates an expected call of FindByEmail
-func (_mr *MockUserRepositoryMockRecorder) FindByEmail(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "FindByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindByEmail), arg0)
+// FindByID indicates an expected call of FindByID.
+func (mr *MockUserRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockUserRepository)(nil).FindByID), ctx, id)
+}
+
+// FindByIDForUpdate mocks base method.
+func (m *MockUserRepository) FindByIDForUpdate(ctx context.Context, id uint64, mode repositories.LockMode) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, id, mode)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockUserRepositoryMockRecorder) FindByIDForUpdate(ctx, id, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockUserRepository)(nil).FindByIDForUpdate), ctx, id, mode)
+}
+
+// FindByTier mocks base method.
+func (m *MockUserRepository) FindByTier(ctx context.Context, tier string, offset, limit int) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByTier", ctx, tier, offset, limit)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByTier indicates an expected call of FindByTier.
+func (mr *MockUserRepositoryMockRecorder) FindByTier(ctx, tier, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTier", reflect.TypeOf((*MockUserRepository)(nil).FindByTier), ctx, tier, offset, limit)
 }
 
-// Save mocks base method
-func (_m *MockUserRepository) Save(user *models.User) (uint64, error) {
-	ret := _m.ctrl.Call(_m, "Save", user)
+// Save mocks base method.
+func (m *MockUserRepository) Save(ctx context.Context, user *models.User) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, user)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Save indicates an expected call of Save
-func (_mr *MockUserRepositoryMockRecorder) Save(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Save", reflect.TypeOf((*MockUserRepository)(nil).Save), arg0)
+// Save indicates an expected call of Save.
+func (mr *MockUserRepositoryMockRecorder) Save(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserRepository)(nil).Save), ctx, user)
 }
 
-// UpdateTotalConsumption mocks base method
-func (_m *MockUserRepository) UpdateTotalConsumption(user *models.User) (int8, error) {
-	ret := _m.ctrl.Call(_m, "UpdateTotalConsumption", user)
+// UpdateFields mocks base method.
+func (m *MockUserRepository) UpdateFields(ctx context.Context, user *models.User, fields ...string) (int8, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, user}
+	for _, a := range fields {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateFields", varargs...)
 	ret0, _ := ret[0].(int8)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateTotalConsumption indicates an expected call of UpdateTotalConsumption
-func (_mr *MockUserRepositoryMockRecorder) UpdateTotalConsumption(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateTotalConsumption", reflect.TypeOf((*MockUserRepository)(nil).UpdateTotalConsumption), arg0)
+// UpdateFields indicates an expected call of UpdateFields.
+func (mr *MockUserRepositoryMockRecorder) UpdateFields(ctx, user interface{}, fields ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, user}, fields...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFields", reflect.TypeOf((*MockUserRepository)(nil).UpdateFields), varargs...)
 }
@@ -1,54 +1,164 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/application/services"
+	"github.com/NorioKe/mysql_demo_use_gorm/domain/models"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/db"
 
 	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/config"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/email"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/i18n"
+	"github.com/NorioKe/mysql_demo_use_gorm/infrastructure/messaging"
 )
 
 func main() {
+	ctx := context.Background()
+	// 读取配置之前使用默认语言
+	catalog := i18n.Default()
+	// log.Fatal 直接退出进程，不执行 defer，因此退出前先等待已开始的异步事件处理完成
+	var dispatcher *messaging.Dispatcher
+	fatal := func(key string, err error) {
+		if dispatcher != nil {
+			dispatcher.Wait()
+		}
+		log.Fatal(catalog.Message(ctx, key, i18n.Params{"error": catalog.Error(ctx, err)}))
+	}
+
 	// 加载配置
 	cfg, err := config.LoadConfig("config.json")
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		fatal("log.load_config_failed", err)
+	}
+	localized, err := i18n.NewCatalog(cfg.Locale)
+	if err != nil {
+		fatal("log.invalid_locale", err)
 	}
+	catalog = localized
 
 	// 初始化数据库
 	gorm_DB, err := db.NewDB(&cfg.Database)
 	if err != nil {
-		log.Fatalf("数据库初始化失败: %v", err)
+		fatal("log.db_init_failed", err)
+	}
+	if err := db.MigrateOrderStatus(gorm_DB); err != nil {
+		fatal("log.order_status_migration_failed", err)
+	}
+
+	// 邮箱校验策略
+	email_policy, err := email.NewPolicy(cfg.Email)
+	if err != nil {
+		fatal("log.email_policy_failed", err)
+	}
+	collisions, err := db.MigrateCanonicalEmail(gorm_DB, email_policy.Canonicalize)
+	for _, c := range collisions {
+		catalog.Log(ctx, "log.email_collision", i18n.Params{"email": c.CanonicalEmail, "user_ids": c.UserIDs})
+	}
+	if err != nil {
+		fatal("log.email_migration_failed", err)
+	}
+
+	// 消费限额
+	if err := cfg.Limits.Validate(); err != nil {
+		fatal("log.invalid_limits", err)
 	}
 
 	// 初始化仓储（repository）
 	user_repo := db.NewGormUserRepository(gorm_DB)
 	order_repo := db.NewGormOrderRepository(gorm_DB)
-	tx_repo := db.NewTransactionManager(gorm_DB)
+	retry := db.DefaultRetryPolicy()
+	retry.Logger = catalog
+	tx_repo := db.NewTransactionManagerWithRetry(gorm_DB, retry)
+	outbox_repo := db.NewGormOutboxRepository(gorm_DB)
+	idempotency_repo := db.NewGormIdempotencyRepository(gorm_DB)
+	rate_repo := db.NewGormExchangeRateRepository(gorm_DB)
+	refund_repo := db.NewGormRefundRepository(gorm_DB)
+	product_repo := db.NewGormProductRepository(gorm_DB)
+	order_item_repo := db.NewGormOrderItemRepository(gorm_DB)
+	email_change_repo := db.NewGormEmailChangeRepository(gorm_DB)
+	audit_repo := db.NewGormAuditRepository(gorm_DB)
+	tier_repo := db.NewGormTierRepository(gorm_DB)
+
+	// 领域事件：同步订阅者在事务提交后立即执行，异步订阅者在后台执行
+	dispatcher = messaging.NewDispatcherWithLogger(catalog)
+	dispatcher.Subscribe(models.EventConsumptionChanged, func(ctx context.Context, event models.DomainEvent) error {
+		e := event.(models.ConsumptionChanged)
+		catalog.Log(ctx, "log.consumption_changed", i18n.Params{"user_id": e.UserID, "delta": e.Delta})
+		return nil
+	})
+	dispatcher.SubscribeAsync(models.EventUserRegistered, func(ctx context.Context, event models.DomainEvent) error {
+		e := event.(models.UserRegistered)
+		catalog.Log(ctx, "log.user_welcome", i18n.Params{"user_id": e.UserID, "email": e.Email})
+		return nil
+	})
+	defer dispatcher.Wait()
 
 	// 初始化应用服务
-	user_service := services.NewUserAppService(user_repo)
-	order_service := services.NewOrderService(user_repo, order_repo, tx_repo)
+	user_service := services.NewUserAppService(user_repo, services.WithEmailPolicy(email_policy),
+		services.WithEmailChange(email_change_repo, tx_repo, 0), services.WithUserEventDispatcher(dispatcher, tx_repo), services.WithUserLogger(catalog))
+	order_service := services.NewOrderService(user_repo, order_repo, tx_repo,
+		services.WithOutbox(outbox_repo), services.WithIdempotency(idempotency_repo),
+		services.WithExchangeRates(rate_repo), services.WithRefunds(refund_repo),
+		services.WithCatalog(product_repo, order_item_repo), services.WithTiers(tier_repo),
+		services.WithSpendingLimits(cfg.Limits), services.WithEventDispatcher(dispatcher), services.WithLogger(catalog))
+	privacy_service := services.NewPrivacyAppService(user_repo, order_repo, refund_repo, email_change_repo, audit_repo, tx_repo)
+	tier_service := services.NewTierAppService(tier_repo, user_repo)
+	outbox_relay := services.NewOutboxRelay(outbox_repo, messaging.NewFilePublisher("outbox_events.jsonl"))
+	outbox_relay.Logger = catalog
 
 	// 示例1: 创建用户
-	user_id, err := user_service.CreateNewUser(services.CreateNewUserCommand{
+	user_id, err := user_service.CreateNewUser(ctx, services.CreateNewUserCommand{
 		Name:  "Xiao Hong",
 		Email: "xiaohong@163.com",
 	})
 	if err != nil {
-		log.Fatalf("创建用户失败: %v", err)
+		fatal("log.create_user_failed", err)
 	}
 	fmt.Printf("User ID: %d\n", user_id)
 
+	// 配置客户等级门槛
+	for _, cmd := range []services.SetTierThresholdCommand{
+		{Tier: "bronze", MinConsumption: models.MustParseMoney("0")},
+		{Tier: "silver", MinConsumption: models.MustParseMoney("1000")},
+		{Tier: "gold", MinConsumption: models.MustParseMoney("10000")},
+	} {
+		if err := tier_service.SetTierThreshold(ctx, cmd); err != nil {
+			fatal("log.set_tier_threshold_failed", err)
+		}
+	}
+
 	// 创建订单
-	err = order_service.CreateOrder(services.CreateOrderCommand{
+	order_id, err := order_service.CreateOrder(ctx, services.CreateOrderCommand{
 		UserID: uint64(1),
-		Amount: float64(1000),
+		Amount: models.MustParseMoney("1000"),
 	})
 	if err != nil {
-		log.Fatalf("创建订单失败: %v", err)
+		fatal("log.create_order_failed", err)
+	}
+	fmt.Printf("Order ID: %d\n", order_id)
+
+	// 查询等级变化
+	tier_changes, err := tier_service.TierHistory(ctx, user_id)
+	if err != nil {
+		fatal("log.tier_history_failed", err)
+	}
+	fmt.Printf("Tier changes: %d\n", len(tier_changes))
+
+	// 导出用户数据
+	archive, err := privacy_service.ExportUserData(ctx, user_id)
+	if err != nil {
+		fatal("log.export_user_failed", err)
+	}
+	fmt.Printf("User export: %d bytes\n", len(archive))
+
+	// 发布发件箱中的事件
+	published, err := outbox_relay.RelayOnce(ctx)
+	if err != nil {
+		fatal("log.relay_failed", err)
 	}
+	fmt.Printf("Published events: %d\n", published)
 }